@@ -9,5 +9,6 @@ require (
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/tdewolff/minify/v2 v2.9.10
 	github.com/tdewolff/parse v2.3.4+incompatible
+	golang.org/x/net v0.17.0
 	golang.org/x/oauth2 v0.0.0-20201208152858-08078c50e5b5
 )
@@ -0,0 +1,61 @@
+-- migrations of already existing tables, applied on every startup
+-- statements which have already been applied are skipped (duplicate column/key errors)
+
+-- alternative text of media, i.e. image descriptions
+ALTER TABLE media ADD COLUMN alt TEXT;
+
+-- typed media, i.e. podcast episodes
+ALTER TABLE media ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'image';
+ALTER TABLE media ADD COLUMN duration INT;
+
+-- live streams, which are pinned on top
+ALTER TABLE content ADD COLUMN live BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- score & comments of link aggregators
+ALTER TABLE content ADD COLUMN score INT;
+ALTER TABLE content ADD COLUMN comments INT;
+ALTER TABLE content ADD COLUMN discussion_url TEXT;
+
+-- video metadata & content labels, which can be hidden per account
+ALTER TABLE content ADD COLUMN label VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE content ADD COLUMN description TEXT;
+ALTER TABLE content ADD COLUMN views BIGINT;
+ALTER TABLE content ADD COLUMN rating FLOAT;
+ALTER TABLE account ADD COLUMN hidden_labels VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE account ADD COLUMN settings_updated_at DATETIME;
+
+-- flair & nsfw/spoiler flags, which are hidden, blurred or shown depending on the user's choice
+ALTER TABLE content ADD COLUMN flair VARCHAR(255);
+ALTER TABLE content ADD COLUMN nsfw BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE content ADD COLUMN spoiler BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user ADD COLUMN nsfw_mode VARCHAR(8) NOT NULL DEFAULT 'blur';
+ALTER TABLE user ADD COLUMN settings_updated_at DATETIME;
+ALTER TABLE media ADD COLUMN poster TEXT;
+
+-- fetch status of channels, i.e. skipped due to a request budget
+ALTER TABLE channel ADD COLUMN last_fetch DATETIME;
+ALTER TABLE channel ADD COLUMN fetch_status VARCHAR(255) NOT NULL DEFAULT '';
+
+-- high-water marks of channels, incremental fetching stops once they are reached
+ALTER TABLE channel ADD COLUMN last_seen_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE channel ADD COLUMN last_seen_date DATETIME;
+
+-- validators of the last response of channels, for conditional requests
+ALTER TABLE channel ADD COLUMN etag VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE channel ADD COLUMN last_modified VARCHAR(255) NOT NULL DEFAULT '';
+
+-- adaptive polling, every channel has its own next fetch
+ALTER TABLE channel ADD COLUMN next_fetch DATETIME;
+
+-- health of the fetches of channels, failing channels back off
+ALTER TABLE channel ADD COLUMN last_success DATETIME;
+ALTER TABLE channel ADD COLUMN last_error VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE channel ADD COLUMN http_status INT NOT NULL DEFAULT 0;
+ALTER TABLE channel ADD COLUMN failure_count INT NOT NULL DEFAULT 0;
+ALTER TABLE channel ADD COLUMN failing_since DATETIME;
+
+-- claims of the fetches, so several instances don't fetch a channel at once
+ALTER TABLE channel ADD COLUMN fetch_claimed_until DATETIME;
+
+-- content, which was updated or removed by a task run
+ALTER TABLE task_run ADD COLUMN updated INT NOT NULL DEFAULT 0;
\ No newline at end of file
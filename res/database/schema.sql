@@ -15,7 +15,9 @@ VALUES ("session", "key", TO_BASE64(RANDOM_BYTES(32)))
 CREATE TABLE IF NOT EXISTS user (
 	id INT AUTO_INCREMENT PRIMARY KEY,
 	email VARCHAR(255) NOT NULL UNIQUE,
-	picture_url TEXT
+	picture_url TEXT,
+	nsfw_mode VARCHAR(8) NOT NULL DEFAULT 'blur', -- "hide", "blur" or "show"
+	settings_updated_at DATETIME -- invalidates the cached card view
 );
 
 -- represents VIFA user's  social media account
@@ -24,6 +26,8 @@ CREATE TABLE IF NOT EXISTS account (
 	name VARCHAR(255) NOT NULL,
 	kind VARCHAR(50) NOT NULL, -- "youtube", "instagram", "reddit", "twitter", ...
 	user_id INT NOT NULL,
+	hidden_labels VARCHAR(255) NOT NULL DEFAULT '', -- content labels hidden in the card view, i.e. "short,live"
+	settings_updated_at DATETIME, -- invalidates the cached card view
 
 	UNIQUE(name, kind),
 	FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
@@ -35,7 +39,20 @@ CREATE TABLE IF NOT EXISTS channel (
 	name VARCHAR(255) NOT NULL,
 	kind VARCHAR(50) NOT NULL, -- "youtube", "instagram", "reddit", "twitter", ...
 	profile_pic TEXT,
-	external_id VARCHAR(255) NOT NULL
+	external_id VARCHAR(255) NOT NULL,
+	last_fetch DATETIME, -- last background fetch
+	fetch_status VARCHAR(255) NOT NULL DEFAULT '', -- error or reason for skipping the last fetch
+	last_seen_id VARCHAR(255) NOT NULL DEFAULT '', -- high-water mark: external id & date of the newest content
+	last_seen_date DATETIME,
+	etag VARCHAR(255) NOT NULL DEFAULT '', -- validators of the last response, for conditional requests
+	last_modified VARCHAR(255) NOT NULL DEFAULT '',
+	next_fetch DATETIME, -- derived from the posting frequency, due immediately if null
+	last_success DATETIME, -- health of the fetches
+	last_error VARCHAR(255) NOT NULL DEFAULT '',
+	http_status INT NOT NULL DEFAULT 0,
+	failure_count INT NOT NULL DEFAULT 0, -- consecutive failures, the polling backs off exponentially
+	failing_since DATETIME,
+	fetch_claimed_until DATETIME -- the channel is being fetched by an instance until then
 );
 
 -- one youtube account can have many channels and one channel can have relations to many accounts
@@ -53,6 +70,17 @@ CREATE TABLE IF NOT EXISTS content (
 	date DATETIME NOT NULL,
 	external_id VARCHAR(256) NOT NULL, -- main url, to the whole content
 	channel_id INT NOT NULL,
+	live BOOLEAN NOT NULL DEFAULT FALSE, -- currently running streams
+	score INT, -- link aggregators, i.e. hacker news
+	comments INT,
+	discussion_url TEXT,
+	label VARCHAR(16) NOT NULL DEFAULT '', -- "short", "live", "premiere", ...
+	description TEXT, -- videos
+	views BIGINT,
+	rating FLOAT,
+	flair VARCHAR(255), -- reddit
+	nsfw BOOLEAN NOT NULL DEFAULT FALSE,
+	spoiler BOOLEAN NOT NULL DEFAULT FALSE,
 
 	UNIQUE(external_id, channel_id),
 	FOREIGN KEY (channel_id) REFERENCES channel(id) ON DELETE CASCADE
@@ -62,6 +90,33 @@ CREATE TABLE IF NOT EXISTS content (
 CREATE TABLE IF NOT EXISTS media (
 	id INT AUTO_INCREMENT PRIMARY KEY,
 	url TEXT NOT NULL, -- thumbnails, etc.
+	type VARCHAR(16) NOT NULL DEFAULT 'image', -- "image", "audio", "video"
+	alt TEXT, -- alternative text, i.e. image descriptions
+	duration INT, -- in seconds, only for playable media
+	poster TEXT, -- preview image of videos
 	content_id INT NOT NULL,
 	FOREIGN KEY (content_id) REFERENCES content(id) ON DELETE CASCADE
+);
+
+-- finished runs of the background tasks, i.e. the fetches of the due channels of a kind
+CREATE TABLE IF NOT EXISTS task_run (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	task VARCHAR(50) NOT NULL, -- kind of the channels, "cleanup" or "mirrors"
+	triggered_by VARCHAR(16) NOT NULL, -- "schedule" or "refresh"
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME NOT NULL,
+	channels INT NOT NULL DEFAULT 0, -- fetched channels
+	items INT NOT NULL DEFAULT 0, -- inserted content
+	updated INT NOT NULL DEFAULT 0, -- updated or removed content
+	errors INT NOT NULL DEFAULT 0, -- failed fetches
+	message VARCHAR(255) NOT NULL DEFAULT '',
+	INDEX(task),
+	INDEX(finished_at)
+);
+
+-- leases of the background tasks, only the owning instance runs a task. expired leases are taken over by other instances
+CREATE TABLE IF NOT EXISTS task_lease (
+	task VARCHAR(50) PRIMARY KEY,
+	owner VARCHAR(100) NOT NULL, -- id of the instance
+	expires_at DATETIME NOT NULL
 );
\ No newline at end of file
@@ -7,12 +7,20 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"time"
 	"visual-feed-aggregator/src/util/logging"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
+// mysql error numbers of migrations, which have already been applied
+const (
+	errDupFieldName = 1060
+	errDupKeyName   = 1061
+)
+
 // OpenDbWithSchema opens up a new connection and makes sure to create the database & schema, in case they dont exist
 func OpenDbWithSchema(user, pass, address string) (*sqlx.DB, error) {
 	var err error
@@ -32,6 +40,10 @@ func OpenDbWithSchema(user, pass, address string) (*sqlx.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = tryMigrateSchema(user, pass, address)
+	if err != nil {
+		return nil, err
+	}
 
 	return sqlx.Connect("mysql", fmt.Sprintf("%s:%s@tcp(%s)/vifa?tls=preferred&parseTime=true", user, pass, address))
 }
@@ -81,3 +93,52 @@ func tryCreateShema(user, pass, address string) error {
 
 	return nil
 }
+
+// tryMigrateSchema applies all statements of the migrations file one by one.
+// the schema file only creates missing tables, so existing tables are altered here.
+// statements which have already been applied fail with duplicate column/key errors, which are ignored
+func tryMigrateSchema(user, pass, address string) error {
+	migrationsFn := "." + string(os.PathSeparator) + path.Join("res", "database", "migrations.sql")
+	migrationsBytes, err := ioutil.ReadFile(migrationsFn)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/vifa", user, pass, address))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, stmt := range splitStatements(string(migrationsBytes)) {
+		_, err = db.Exec(stmt)
+		// beware: these errors only tell, that a column/key of the same name exists, not that it matches the statement.
+		// a half-applied migration or a column, whose definition was changed later on, is skipped silently.
+		// so each statement adds a single column/key & existing columns are never redefined here
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+			if mysqlErr.Number == errDupFieldName || mysqlErr.Number == errDupKeyName {
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a sql file into its statements, comment lines are dropped
+func splitStatements(sqlStr string) []string {
+	var sb strings.Builder
+	for _, line := range strings.Split(sqlStr, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			sb.WriteString(line + "\n")
+		}
+	}
+	ret := []string{}
+	for _, stmt := range strings.Split(sb.String(), ";") {
+		if strings.TrimSpace(stmt) != "" {
+			ret = append(ret, strings.TrimSpace(stmt))
+		}
+	}
+	return ret
+}
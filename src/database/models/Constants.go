@@ -1,5 +1,23 @@
 package models
 
+const (
+	// MediaImage is ~enum for pictures & thumbnails
+	MediaImage = "image"
+	// MediaAudio is ~enum for audio files, i.e. podcast episodes
+	MediaAudio = "audio"
+	// MediaVideo is ~enum for video files, i.e. reddit hosted videos
+	MediaVideo = "video"
+)
+
+const (
+	// NsfwHide hides nsfw & spoiler content in the card view
+	NsfwHide = "hide"
+	// NsfwBlur blurs the media of nsfw & spoiler content until hovered
+	NsfwBlur = "blur"
+	// NsfwShow shows nsfw & spoiler content as is
+	NsfwShow = "show"
+)
+
 const (
 	// KindYoutube is ~enum for youtube
 	KindYoutube = "youtube"
@@ -9,4 +27,26 @@ const (
 	KindReddit = "reddit"
 	// KindTwitter is ~enum for twitter
 	KindTwitter = "twitter"
+	// KindFeed is ~enum for generic rss/atom/json feeds
+	KindFeed = "feed"
+	// KindMastodon is ~enum for mastodon (and other activitypub servers implementing its api)
+	KindMastodon = "mastodon"
+	// KindBluesky is ~enum for bluesky (at protocol)
+	KindBluesky = "bluesky"
+	// KindPeerTube is ~enum for peertube (any instance)
+	KindPeerTube = "peertube"
+	// KindOdysee is ~enum for odysee (lbry)
+	KindOdysee = "odysee"
+	// KindPodcast is ~enum for podcasts (rss feeds with audio enclosures)
+	KindPodcast = "podcast"
+	// KindTwitch is ~enum for twitch
+	KindTwitch = "twitch"
+	// KindLemmy is ~enum for lemmy communities (any instance)
+	KindLemmy = "lemmy"
+	// KindGithub is ~enum for github repositories
+	KindGithub = "github"
+	// KindHackerNews is ~enum for hacker news
+	KindHackerNews = "hackernews"
+	// KindLobsters is ~enum for lobste.rs
+	KindLobsters = "lobsters"
 )
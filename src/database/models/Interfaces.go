@@ -30,10 +30,14 @@ type AccountRepository interface {
 type ChannelRepository interface {
 	CreateChannel(channel *Channel) error
 	GetChannel(id int64) (Channel, error)
-	FindChannelByExternalID(externalID string) (Channel, error)
+	FindChannelByExternalID(kind, externalID string) (Channel, error)
 	FindChannelsByKind(kind string) ([]Channel, error)
+	FindDueChannelsByKind(kind string, now time.Time) ([]Channel, error)
 	FindChannelsByAccountIDAndKind(accountID int64, kind string) ([]Channel, error)
 	UpdateChannel(channel Channel) error
+	UpdateFetchStatus(channel Channel) error
+	ClaimChannelFetch(id int64, ttl time.Duration) (bool, error)
+	ReleaseChannelFetch(id int64) error
 	RemoveChannel(channel Channel) error
 	LoadFollowers(channel *Channel) error
 	LoadContent(channel *Channel) error
@@ -46,11 +50,14 @@ type ContentRepository interface {
 	GetContent(id int64) (Content, error)
 	UpdateContent(content Content) error
 	RemoveContent(content Content) error
+	UpdateContentStats(content Content) error
+	RemoveLiveContent(channelID int64) (int64, error)
 	LoadChannel(content *Content) error
 	LoadMedia(content *Content) error
 	CleanupOldContent(time *time.Time) (int64, error)
 	LoadContentFor(userID int64, kind string, accID int64, offset, count int64) ([]Content, error)
 	CountAllContentFor(userID int64, kind string, accID int64) (int64, error)
+	CountChannelContentSince(channelID int64, since time.Time) (int64, error)
 }
 
 // MediaRepository ...
@@ -60,3 +67,19 @@ type MediaRepository interface {
 	UpdateMedia(media Media) error
 	RemoveMedia(media Media) error
 }
+
+// TaskRunRepository ...
+type TaskRunRepository interface {
+	CreateTaskRun(run *TaskRun) error
+	FindRecentTaskRuns(limit int) ([]TaskRun, error)
+	FindLastTaskRuns(changedContent bool) ([]TaskRun, error)
+	CleanupOldTaskRuns(before time.Time) (int64, error)
+}
+
+// TaskLeaseRepository ...
+type TaskLeaseRepository interface {
+	AcquireTaskLease(task, owner string, ttl time.Duration) (bool, error)
+	RenewTaskLeases(owner string, ttl time.Duration) error
+	ReleaseTaskLeases(owner string) error
+	FindTaskLeases() ([]TaskLease, error)
+}
@@ -11,6 +11,10 @@ type Account struct {
 	Name   string
 	Kind   string
 	UserID int64 `db:"user_id"`
+	// comma separated content labels (i.e. "short,live"), which are hidden in the card view
+	HiddenLabels string `db:"hidden_labels"`
+	// last change of the settings, the cached card view is stale afterwards
+	SettingsUpdatedAt sql.NullTime `db:"settings_updated_at"`
 
 	User     *User
 	Channels []Channel
@@ -21,6 +25,9 @@ type User struct {
 	ID         int64
 	Email      string
 	PictureURL string `db:"picture_url"`
+	NsfwMode   string `db:"nsfw_mode"` // NsfwHide, NsfwBlur or NsfwShow
+	// last change of the settings, the cached card view is stale afterwards
+	SettingsUpdatedAt sql.NullTime `db:"settings_updated_at"`
 
 	Accounts []Account
 }
@@ -32,6 +39,25 @@ type Channel struct {
 	Kind       string
 	ProfilePic sql.NullString `db:"profile_pic"`
 	ExternalID string         `db:"external_id"`
+	// outcome of the last background fetch
+	LastFetch   sql.NullTime `db:"last_fetch"`
+	FetchStatus string       `db:"fetch_status"` // error or reason for skipping, empty if fine
+	// high-water mark, the newest content seen so far
+	LastSeenID   string       `db:"last_seen_id"`
+	LastSeenDate sql.NullTime `db:"last_seen_date"`
+	// validators of the last response, for conditional requests
+	ETag         string `db:"etag"`
+	LastModified string `db:"last_modified"`
+	// next background fetch, derived from the posting frequency
+	NextFetch sql.NullTime `db:"next_fetch"`
+	// health of the fetches, the failures are reset by the next success
+	LastSuccess  sql.NullTime `db:"last_success"`
+	LastError    string       `db:"last_error"`    // kept after the channel recovered
+	HTTPStatus   int          `db:"http_status"`   // of the last error, 0 if it wasn't a http error
+	FailureCount int          `db:"failure_count"` // consecutive failures
+	FailingSince sql.NullTime `db:"failing_since"`
+	// claim of the fetch, which is shared by all instances
+	FetchClaimedUntil sql.NullTime `db:"fetch_claimed_until"`
 
 	Followers []Account
 	Contents  []Content
@@ -44,6 +70,19 @@ type Content struct {
 	Date       time.Time
 	ExternalID string `db:"external_id"` // 255 chars
 	ChannelID  int64  `db:"channel_id"`
+	Live       bool   // currently running streams are pinned on top
+	Label      string // i.e. "short", "live" or "premiere", can be hidden per account
+	// videos
+	Description sql.NullString
+	Views       sql.NullInt64
+	Rating      sql.NullFloat64
+	// link aggregators
+	Score         sql.NullInt64
+	Comments      sql.NullInt64
+	DiscussionURL sql.NullString `db:"discussion_url"`
+	Flair         sql.NullString
+	Nsfw          bool
+	Spoiler       bool
 
 	Channel  *Channel
 	AllMedia []Media
@@ -53,7 +92,38 @@ type Content struct {
 type Media struct {
 	ID        int64
 	URL       string
-	ContentID int64 `db:"content_id"`
+	Type      string         // MediaImage, MediaAudio, MediaVideo
+	Alt       sql.NullString // alternative text, i.e. image description
+	Duration  sql.NullInt64  // in seconds, only for playable media
+	Poster    sql.NullString // preview image of videos
+	ContentID int64          `db:"content_id"`
 
 	Content *Content
 }
+
+// TaskRun is a finished run of a background task, i.e. the fetch of the due channels of a kind
+type TaskRun struct {
+	ID          int64
+	Task        string    // kind of the channels, "cleanup" or "mirrors"
+	TriggeredBy string    `db:"triggered_by"` // TriggerSchedule or TriggerRefresh
+	StartedAt   time.Time `db:"started_at"`
+	FinishedAt  time.Time `db:"finished_at"`
+	Channels    int       // fetched channels
+	Items       int       // inserted content
+	Updated     int       // updated or removed content, i.e. statistics & ended live streams
+	Errors      int       // failed fetches
+	Message     string    // the last error or a summary of the run
+}
+
+// triggers of task runs
+const (
+	TriggerSchedule = "schedule"
+	TriggerRefresh  = "refresh"
+)
+
+// TaskLease grants an instance the exclusive right to run a task, until it expires without being renewed
+type TaskLease struct {
+	Task      string
+	Owner     string    // id of the instance
+	ExpiresAt time.Time `db:"expires_at"`
+}
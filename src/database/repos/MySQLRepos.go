@@ -31,6 +31,14 @@ type mySQLMediaRepository struct {
 	db *sqlx.DB
 }
 
+type mySQLTaskRunRepository struct {
+	db *sqlx.DB
+}
+
+type mySQLTaskLeaseRepository struct {
+	db *sqlx.DB
+}
+
 // NewMySQLUserRepository ...
 func NewMySQLUserRepository(db *sqlx.DB) models.UserRepository {
 	return &mySQLUserRepository{db: db}
@@ -65,7 +73,7 @@ func (r *mySQLUserRepository) GetUser(email string) (models.User, error) {
 func (r *mySQLUserRepository) UpdateUser(user models.User) error {
 	query := `
 	UPDATE user
-	SET email = :email, picture_url = :picture_url
+	SET email = :email, picture_url = :picture_url, nsfw_mode = :nsfw_mode, settings_updated_at = :settings_updated_at
 	WHERE id=:id
 	`
 	_, err := r.db.NamedExec(query, user)
@@ -148,7 +156,7 @@ func (r *mySQLAccountRepository) GetAccount(id int64) (models.Account, error) {
 func (r *mySQLAccountRepository) UpdateAccount(account models.Account) error {
 	query := `
 	UPDATE account
-	SET name = :name, kind = :kind, user_id = :user_id
+	SET name = :name, kind = :kind, user_id = :user_id, hidden_labels = :hidden_labels, settings_updated_at = :settings_updated_at
 	WHERE id = :id
 	`
 	_, err := r.db.NamedExec(query, &account)
@@ -261,14 +269,14 @@ func (r *mySQLChannelRepository) GetChannel(id int64) (models.Channel, error) {
 	return channel, err
 }
 
-func (r *mySQLChannelRepository) FindChannelByExternalID(externalID string) (models.Channel, error) {
+func (r *mySQLChannelRepository) FindChannelByExternalID(kind, externalID string) (models.Channel, error) {
 	query := `
 	SELECT *
 	FROM channel
-	WHERE external_id = ?
+	WHERE kind = ? AND external_id = ?
 	`
 	channel := models.Channel{}
-	err := r.db.Get(&channel, query, externalID)
+	err := r.db.Get(&channel, query, kind, externalID)
 	return channel, err
 }
 
@@ -283,6 +291,18 @@ func (r *mySQLChannelRepository) FindChannelsByKind(kind string) ([]models.Chann
 	return channels, err
 }
 
+func (r *mySQLChannelRepository) FindDueChannelsByKind(kind string, now time.Time) ([]models.Channel, error) {
+	query := `
+	SELECT *
+	FROM channel
+	WHERE kind = ? AND (next_fetch IS NULL OR next_fetch <= ?)
+	ORDER BY next_fetch
+	`
+	channels := []models.Channel{}
+	err := r.db.Select(&channels, query, kind, now)
+	return channels, err
+}
+
 func (r *mySQLChannelRepository) FindChannelsByAccountIDAndKind(accountID int64, kind string) ([]models.Channel, error) {
 	query := `
 	SELECT channel.*
@@ -306,6 +326,45 @@ func (r *mySQLChannelRepository) UpdateChannel(Channel models.Channel) error {
 	return err
 }
 
+func (r *mySQLChannelRepository) UpdateFetchStatus(channel models.Channel) error {
+	query := `
+	UPDATE channel
+	SET last_fetch = :last_fetch, fetch_status = :fetch_status, last_seen_id = :last_seen_id, last_seen_date = :last_seen_date,
+		etag = :etag, last_modified = :last_modified, next_fetch = :next_fetch,
+		last_success = :last_success, last_error = :last_error, http_status = :http_status,
+		failure_count = :failure_count, failing_since = :failing_since
+	WHERE id = :id
+	`
+	_, err := r.db.NamedExec(query, channel)
+	return err
+}
+
+// ClaimChannelFetch claims the fetch of a channel for all instances, unless it's claimed already.
+// the claim expires after the ttl, in case the instance dies while fetching
+func (r *mySQLChannelRepository) ClaimChannelFetch(id int64, ttl time.Duration) (bool, error) {
+	query := `
+	UPDATE channel
+	SET fetch_claimed_until = UTC_TIMESTAMP() + INTERVAL ? SECOND
+	WHERE id = ? AND (fetch_claimed_until IS NULL OR fetch_claimed_until < UTC_TIMESTAMP())
+	`
+	res, err := r.db.Exec(query, int64(ttl.Seconds()), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *mySQLChannelRepository) ReleaseChannelFetch(id int64) error {
+	query := `
+	UPDATE channel
+	SET fetch_claimed_until = NULL
+	WHERE id = ?
+	`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
 func (r *mySQLChannelRepository) RemoveChannel(Channel models.Channel) error {
 	query := `
 	DELETE FROM channel
@@ -375,8 +434,8 @@ func NewMySQLContentRepository(db *sqlx.DB) models.ContentRepository {
 
 func (r *mySQLContentRepository) CreateContent(content *models.Content) error {
 	query := `
-	INSERT INTO content (title, date, external_id, channel_id) 
-	VALUES (:title, :date, :external_id, :channel_id)
+	INSERT INTO content (title, date, external_id, channel_id, live, score, comments, discussion_url, label, description, views, rating, flair, nsfw, spoiler) 
+	VALUES (:title, :date, :external_id, :channel_id, :live, :score, :comments, :discussion_url, :label, :description, :views, :rating, :flair, :nsfw, :spoiler)
 	`
 	res, err := r.db.NamedExec(query, &content)
 	if err == nil {
@@ -400,7 +459,10 @@ func (r *mySQLContentRepository) GetContent(id int64) (models.Content, error) {
 func (r *mySQLContentRepository) UpdateContent(content models.Content) error {
 	query := `
 	UPDATE content
-	SET title = :title, date = :date, external_id = :external_id, channel_id = :channel_id
+	SET title = :title, date = :date, external_id = :external_id, channel_id = :channel_id, live = :live,
+		score = :score, comments = :comments, discussion_url = :discussion_url,
+		label = :label, description = :description, views = :views, rating = :rating,
+		flair = :flair, nsfw = :nsfw, spoiler = :spoiler
 	WHERE id=:id
 	`
 	_, err := r.db.NamedExec(query, content)
@@ -417,6 +479,28 @@ func (r *mySQLContentRepository) RemoveContent(content models.Content) error {
 	return err
 }
 
+func (r *mySQLContentRepository) UpdateContentStats(content models.Content) error {
+	query := `
+	UPDATE content
+	SET score = :score, comments = :comments, views = :views, rating = :rating
+	WHERE external_id = :external_id AND channel_id = :channel_id
+	`
+	_, err := r.db.NamedExec(query, content)
+	return err
+}
+
+func (r *mySQLContentRepository) RemoveLiveContent(channelID int64) (int64, error) {
+	query := `
+	DELETE FROM content
+	WHERE channel_id = ? AND live = TRUE
+	`
+	res, err := r.db.Exec(query, channelID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (r *mySQLContentRepository) LoadChannel(content *models.Content) error {
 	query := `
 	SELECT *
@@ -466,8 +550,9 @@ func (r *mySQLContentRepository) LoadContentFor(userID int64, kind string, accID
 	query := `
 	SELECT
 		ch.id, ch.name, ch.kind, ch.profile_pic, ch.external_id,
-		c.id, c.title, c.date, c.external_id, c.channel_id,
-		m.id, m.url, m.content_id
+		c.id, c.title, c.date, c.external_id, c.channel_id, c.live, c.score, c.comments, c.discussion_url,
+		c.label, c.description, c.views, c.rating, c.flair, c.nsfw, c.spoiler,
+		m.id, m.url, m.type, m.alt, m.duration, m.poster, m.content_id
 	FROM (
 		SELECT DISTINCT c2.* 
 		FROM content c2
@@ -475,7 +560,7 @@ func (r *mySQLContentRepository) LoadContentFor(userID int64, kind string, accID
 		INNER JOIN account_channel ac2 ON ac2.channel_id = ch2.id
 		INNER JOIN account a2 ON a2.id =  ac2.account_id    
 		%s
-		ORDER BY c2.date DESC
+		ORDER BY c2.live DESC, c2.date DESC
 		%s
 	) as c
 		LEFT JOIN channel ch ON ch.id = c.channel_id
@@ -492,6 +577,8 @@ func (r *mySQLContentRepository) LoadContentFor(userID int64, kind string, accID
 		sqlWhere = "WHERE a2.user_id = ? AND a2.kind = ?"
 		args = append(args, userID, kind)
 	}
+	sqlWhere += " AND (c2.label = '' OR FIND_IN_SET(c2.label, a2.hidden_labels) = 0)" // hidden content labels of the account
+	sqlWhere += " AND " + nsfwFilter("c2", "a2")
 	sqlLimit := ""
 	if offset >= 0 && count > 0 {
 		sqlLimit = "LIMIT ?, ?"
@@ -512,8 +599,9 @@ func (r *mySQLContentRepository) LoadContentFor(userID int64, kind string, accID
 		var c models.Content
 		var m models.Media
 		err := rows.Scan(&ch.ID, &ch.Name, &ch.Kind, &ch.ProfilePic, &ch.ExternalID,
-			&c.ID, &c.Title, &c.Date, &c.ExternalID, &c.ChannelID,
-			&m.ID, &m.URL, &m.ContentID)
+			&c.ID, &c.Title, &c.Date, &c.ExternalID, &c.ChannelID, &c.Live, &c.Score, &c.Comments, &c.DiscussionURL,
+			&c.Label, &c.Description, &c.Views, &c.Rating, &c.Flair, &c.Nsfw, &c.Spoiler,
+			&m.ID, &m.URL, &m.Type, &m.Alt, &m.Duration, &m.Poster, &m.ContentID)
 		if err != nil {
 			logging.Println(logging.Debug, err)
 			// media can be null and it will throw conversion error -- some content may not have any associated media!
@@ -554,11 +642,11 @@ func (r *mySQLContentRepository) CountAllContentFor(userID int64, kind string, a
 	;`
 	var row *sql.Row
 	if accID > 0 {
-		query = fmt.Sprintf(query, "WHERE a.user_id = ? AND a.kind = ? AND a.id = ?")
-		row = r.db.QueryRow(query, 1, kind, accID)
+		query = fmt.Sprintf(query, "WHERE a.user_id = ? AND a.kind = ? AND a.id = ? AND (c.label = '' OR FIND_IN_SET(c.label, a.hidden_labels) = 0) AND "+nsfwFilter("c", "a"))
+		row = r.db.QueryRow(query, userID, kind, accID)
 	} else {
-		query = fmt.Sprintf(query, "WHERE a.user_id = ? AND a.kind = ?")
-		row = r.db.QueryRow(query, 1, kind)
+		query = fmt.Sprintf(query, "WHERE a.user_id = ? AND a.kind = ? AND (c.label = '' OR FIND_IN_SET(c.label, a.hidden_labels) = 0) AND "+nsfwFilter("c", "a"))
+		row = r.db.QueryRow(query, userID, kind)
 	}
 	var count int64
 	err := row.Scan(&count)
@@ -569,6 +657,23 @@ func (r *mySQLContentRepository) CountAllContentFor(userID int64, kind string, a
 	return count, nil
 }
 
+func (r *mySQLContentRepository) CountChannelContentSince(channelID int64, since time.Time) (int64, error) {
+	query := `
+	SELECT count(*)
+	FROM content
+	WHERE channel_id = ? AND date >= ? AND live = FALSE
+	`
+	var count int64
+	err := r.db.Get(&count, query, channelID, since)
+	return count, err
+}
+
+// nsfwFilter skips nsfw & spoiler content, if the user of the account chose to hide it
+func nsfwFilter(content, account string) string {
+	return fmt.Sprintf("((%[1]s.nsfw = FALSE AND %[1]s.spoiler = FALSE) OR (SELECT u.nsfw_mode FROM user u WHERE u.id = %[2]s.user_id) <> '%[3]s')",
+		content, account, models.NsfwHide)
+}
+
 // NewMySQLMediaRepository ...
 func NewMySQLMediaRepository(db *sqlx.DB) models.MediaRepository {
 	return &mySQLMediaRepository{db: db}
@@ -576,8 +681,8 @@ func NewMySQLMediaRepository(db *sqlx.DB) models.MediaRepository {
 
 func (r *mySQLMediaRepository) CreateMedia(media *models.Media) error {
 	createMediaQuery := `
-	INSERT INTO media (url, content_id) 
-	VALUES (:url, :content_id)
+	INSERT INTO media (url, type, alt, duration, poster, content_id) 
+	VALUES (:url, :type, :alt, :duration, :poster, :content_id)
 	`
 	res, err := r.db.NamedExec(createMediaQuery, &media)
 	if err == nil {
@@ -600,7 +705,7 @@ func (r *mySQLMediaRepository) GetMedia(id int64) (models.Media, error) {
 func (r *mySQLMediaRepository) UpdateMedia(media models.Media) error {
 	updateMediaQuery := `
 	UPDATE media
-	SET url = :url, content_id = :content_id
+	SET url = :url, type = :type, alt = :alt, duration = :duration, poster = :poster, content_id = :content_id
 	WHERE id = :id
 	`
 	_, err := r.db.NamedExec(updateMediaQuery, media)
@@ -615,3 +720,120 @@ func (r *mySQLMediaRepository) RemoveMedia(media models.Media) error {
 	_, err := r.db.NamedExec(removeMediaQuery, &media)
 	return err
 }
+
+// NewMySQLTaskRunRepository creates a new mysql task run repository
+func NewMySQLTaskRunRepository(db *sqlx.DB) models.TaskRunRepository {
+	return &mySQLTaskRunRepository{db: db}
+}
+
+func (r *mySQLTaskRunRepository) CreateTaskRun(run *models.TaskRun) error {
+	query := `
+	INSERT INTO task_run (task, triggered_by, started_at, finished_at, channels, items, updated, errors, message)
+	VALUES (:task, :triggered_by, :started_at, :finished_at, :channels, :items, :updated, :errors, :message)
+	`
+	res, err := r.db.NamedExec(query, run)
+	if err == nil {
+		run.ID, err = res.LastInsertId()
+	}
+	return err
+}
+
+func (r *mySQLTaskRunRepository) FindRecentTaskRuns(limit int) ([]models.TaskRun, error) {
+	query := `
+	SELECT *
+	FROM task_run
+	ORDER BY id DESC
+	LIMIT ?
+	`
+	runs := []models.TaskRun{}
+	err := r.db.Select(&runs, query, limit)
+	return runs, err
+}
+
+// FindLastTaskRuns returns the last run of every task, optionally the last run, which changed content
+func (r *mySQLTaskRunRepository) FindLastTaskRuns(changedContent bool) ([]models.TaskRun, error) {
+	query := `
+	SELECT t.*
+	FROM task_run t
+	INNER JOIN (
+		SELECT MAX(id) AS id
+		FROM task_run
+		WHERE ? = FALSE OR items > 0 OR updated > 0
+		GROUP BY task
+	) last ON last.id = t.id
+	`
+	runs := []models.TaskRun{}
+	err := r.db.Select(&runs, query, changedContent)
+	return runs, err
+}
+
+func (r *mySQLTaskRunRepository) CleanupOldTaskRuns(before time.Time) (int64, error) {
+	query := `
+	DELETE FROM task_run
+	WHERE finished_at < ?
+	`
+	res, err := r.db.Exec(query, before)
+	if err == nil {
+		cnt, err := res.RowsAffected()
+		if err != nil {
+			return 0, nil // nil because RowsAffected is an optional feature db dependent, not indicative of an error
+		}
+		return cnt, nil
+	}
+	return 0, err
+}
+
+// NewMySQLTaskLeaseRepository creates a new mysql task lease repository
+func NewMySQLTaskLeaseRepository(db *sqlx.DB) models.TaskLeaseRepository {
+	return &mySQLTaskLeaseRepository{db: db}
+}
+
+// AcquireTaskLease takes over an expired lease or renews the own one, the expiry is based on the clock of the database
+// mysql assigns from left to right, so the expiry sees the updated owner
+func (r *mySQLTaskLeaseRepository) AcquireTaskLease(task, owner string, ttl time.Duration) (bool, error) {
+	query := `
+	INSERT INTO task_lease (task, owner, expires_at)
+	VALUES (?, ?, UTC_TIMESTAMP() + INTERVAL ? SECOND)
+	ON DUPLICATE KEY UPDATE
+		owner = IF(expires_at < UTC_TIMESTAMP() OR owner = ?, ?, owner),
+		expires_at = IF(owner = ?, UTC_TIMESTAMP() + INTERVAL ? SECOND, expires_at)
+	`
+	seconds := int64(ttl.Seconds())
+	_, err := r.db.Exec(query, task, owner, seconds, owner, owner, owner, seconds)
+	if err != nil {
+		return false, err
+	}
+	var current string
+	err = r.db.Get(&current, "SELECT owner FROM task_lease WHERE task = ?", task)
+	return current == owner, err
+}
+
+func (r *mySQLTaskLeaseRepository) RenewTaskLeases(owner string, ttl time.Duration) error {
+	query := `
+	UPDATE task_lease
+	SET expires_at = UTC_TIMESTAMP() + INTERVAL ? SECOND
+	WHERE owner = ? AND expires_at >= UTC_TIMESTAMP()
+	`
+	_, err := r.db.Exec(query, int64(ttl.Seconds()), owner)
+	return err
+}
+
+func (r *mySQLTaskLeaseRepository) ReleaseTaskLeases(owner string) error {
+	query := `
+	DELETE FROM task_lease
+	WHERE owner = ?
+	`
+	_, err := r.db.Exec(query, owner)
+	return err
+}
+
+func (r *mySQLTaskLeaseRepository) FindTaskLeases() ([]models.TaskLease, error) {
+	query := `
+	SELECT *
+	FROM task_lease
+	WHERE expires_at >= UTC_TIMESTAMP()
+	`
+	leases := []models.TaskLease{}
+	err := r.db.Select(&leases, query)
+	return leases, err
+}
@@ -1,6 +1,10 @@
 package services
 
-import "visual-feed-aggregator/src/database/models"
+import (
+	"database/sql"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+)
 
 type accountService struct {
 	accountRepo models.AccountRepository
@@ -25,6 +29,16 @@ func (s *accountService) GetAccount(id int64) (models.Account, error) {
 	return s.accountRepo.GetAccount(id)
 }
 
+func (s *accountService) SetHiddenLabels(accountID int64, hiddenLabels string) error {
+	acc, err := s.accountRepo.GetAccount(accountID)
+	if err != nil {
+		return err
+	}
+	acc.HiddenLabels = hiddenLabels
+	acc.SettingsUpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	return s.accountRepo.UpdateAccount(acc)
+}
+
 func (s *accountService) HasChannel(accountID, channelID int64) bool {
 	return s.accountRepo.HasChannel(accountID, channelID)
 }
@@ -2,7 +2,7 @@ package services
 
 import (
 	"database/sql"
-	"strings"
+	"time"
 	"visual-feed-aggregator/src/database/models"
 )
 
@@ -16,7 +16,7 @@ func NewChannelService(channelRepo models.ChannelRepository) ChannelService {
 }
 
 func (s *channelService) CreateChannelIfNotExists(name, kind, profilePic, externalID string) (models.Channel, bool, error) {
-	c, err := s.channelRepo.FindChannelByExternalID(externalID)
+	c, err := s.channelRepo.FindChannelByExternalID(kind, externalID)
 	if err == nil {
 		return c, false, nil
 	}
@@ -29,31 +29,24 @@ func (s *channelService) FindChannelsByKind(kind string) ([]models.Channel, erro
 	return s.channelRepo.FindChannelsByKind(kind)
 }
 
+func (s *channelService) FindDueChannelsByKind(kind string, now time.Time) ([]models.Channel, error) {
+	return s.channelRepo.FindDueChannelsByKind(kind, now)
+}
+
 func (s *channelService) FindChannelsByAccountIDAndKind(accountID int64, kind string) ([]models.Channel, error) {
-	ret, err := s.channelRepo.FindChannelsByAccountIDAndKind(accountID, kind)
-	if err == nil {
-		for idx := range ret {
-			ret[idx].ExternalID = ChannelURL(ret[idx].ExternalID, kind)
-		}
-	}
-	return ret, err
+	return s.channelRepo.FindChannelsByAccountIDAndKind(accountID, kind)
 }
 
-// ChannelURL returns the (full) URL for a given externalID (which is just the shortest possible ID to reconstruct the full URL)
-func ChannelURL(externalID, kind string) string {
-	switch kind {
-	case models.KindYoutube:
-		split := strings.Split(externalID, "=")
-		cu := strings.Replace(split[0], "_id", "", -1)
-		return "https://youtube.com/" + cu + "/" + split[1]
-	case models.KindReddit:
-		return "https://reddit.com/r/" + externalID + "/new"
-	case models.KindTwitter:
-		return "https://twitter.com/" + externalID
-	case models.KindInstagram:
-		return "https://instagram.com/" + externalID
-	}
-	return externalID
+func (s *channelService) UpdateFetchStatus(channel models.Channel) error {
+	return s.channelRepo.UpdateFetchStatus(channel)
+}
+
+func (s *channelService) ClaimChannelFetch(id int64, ttl time.Duration) (bool, error) {
+	return s.channelRepo.ClaimChannelFetch(id, ttl)
+}
+
+func (s *channelService) ReleaseChannelFetch(id int64) error {
+	return s.channelRepo.ReleaseChannelFetch(id)
 }
 
 func (s *channelService) LoadContent(channel *models.Channel) error {
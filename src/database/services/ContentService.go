@@ -18,6 +18,18 @@ func (s *contentService) CreateContent(content *models.Content) error {
 	return s.contentRepo.CreateContent(content)
 }
 
+func (s *contentService) UpdateContent(content models.Content) error {
+	return s.contentRepo.UpdateContent(content)
+}
+
+func (s *contentService) UpdateContentStats(content models.Content) error {
+	return s.contentRepo.UpdateContentStats(content)
+}
+
+func (s *contentService) RemoveLiveContent(channelID int64) (int64, error) {
+	return s.contentRepo.RemoveLiveContent(channelID)
+}
+
 func (s *contentService) LoadMedia(content *models.Content) error {
 	return s.contentRepo.LoadMedia(content)
 }
@@ -32,3 +44,7 @@ func (s *contentService) LoadContentFor(userID int64, kind string, accID int64,
 func (s *contentService) CountAllContentFor(userID int64, kind string, accID int64) (int64, error) {
 	return s.contentRepo.CountAllContentFor(userID, kind, accID)
 }
+
+func (s *contentService) CountChannelContentSince(channelID int64, since time.Time) (int64, error) {
+	return s.contentRepo.CountChannelContentSince(channelID, since)
+}
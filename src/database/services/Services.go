@@ -14,6 +14,7 @@ type UserService interface {
 	LoadUserAccounts(user *models.User) error
 	LoadUserAccountsForSocialMedia(user *models.User, kind string) error
 	CreateUserIfNotExists(email, pictureURL string) (models.User, bool, error)
+	SetNsfwMode(user models.User, nsfwMode string) error
 }
 
 // AccountService defines all the necessary business logic
@@ -21,6 +22,7 @@ type AccountService interface {
 	AddAccount(userID int64, name, kind string) (models.Account, error)
 	RemoveAccount(accountID int64) error
 	GetAccount(id int64) (models.Account, error)
+	SetHiddenLabels(accountID int64, hiddenLabels string) error
 	HasChannel(accountID, channelID int64) bool
 	AddChannel(account *models.Account, channel models.Channel) error
 	RemoveAccountChannel(accountID, channelID int64) error
@@ -30,7 +32,11 @@ type AccountService interface {
 type ChannelService interface {
 	CreateChannelIfNotExists(name, kind, profilePic, externalID string) (models.Channel, bool, error)
 	FindChannelsByKind(kind string) ([]models.Channel, error)
+	FindDueChannelsByKind(kind string, now time.Time) ([]models.Channel, error)
 	FindChannelsByAccountIDAndKind(accountID int64, kind string) ([]models.Channel, error)
+	UpdateFetchStatus(channel models.Channel) error
+	ClaimChannelFetch(id int64, ttl time.Duration) (bool, error)
+	ReleaseChannelFetch(id int64) error
 	LoadContent(channel *models.Channel) error
 	CleanupOrphanedChannels() (int64, error)
 }
@@ -38,10 +44,14 @@ type ChannelService interface {
 // ContentService ...
 type ContentService interface {
 	CreateContent(content *models.Content) error
+	UpdateContent(content models.Content) error
+	UpdateContentStats(content models.Content) error
+	RemoveLiveContent(channelID int64) (int64, error)
 	LoadMedia(content *models.Content) error
 	CleanupOldContent(time *time.Time) (int64, error)
 	LoadContentFor(userID int64, kind string, accID int64, offset, count int64) ([]models.Content, error)
 	CountAllContentFor(userID int64, kind string, accID int64) (int64, error)
+	CountChannelContentSince(channelID int64, since time.Time) (int64, error)
 }
 
 // MediaService ...
@@ -49,6 +59,18 @@ type MediaService interface {
 	CreateMedia(media *models.Media) error
 }
 
+// TaskService ...
+type TaskService interface {
+	CreateTaskRun(run *models.TaskRun) error
+	FindRecentTaskRuns(limit int) ([]models.TaskRun, error)
+	FindLastTaskRuns(changedContent bool) ([]models.TaskRun, error)
+	CleanupOldTaskRuns(before time.Time) (int64, error)
+	AcquireTaskLease(task, owner string, ttl time.Duration) (bool, error)
+	RenewTaskLeases(owner string, ttl time.Duration) error
+	ReleaseTaskLeases(owner string) error
+	FindTaskLeases() ([]models.TaskLease, error)
+}
+
 // ServiceCollection ...
 type ServiceCollection struct {
 	UserService    UserService
@@ -56,6 +78,7 @@ type ServiceCollection struct {
 	ChannelService ChannelService
 	ContentService ContentService
 	MediaService   MediaService
+	TaskService    TaskService
 }
 
 // NewMySQLServiceCollection ...
@@ -66,5 +89,6 @@ func NewMySQLServiceCollection(db *sqlx.DB) ServiceCollection {
 		ChannelService: NewChannelService(repos.NewMySQLChannelRepository(db)),
 		ContentService: NewContentService(repos.NewMySQLContentRepository(db)),
 		MediaService:   NewMediaService(repos.NewMySQLMediaRepository(db)),
+		TaskService:    NewTaskService(repos.NewMySQLTaskRunRepository(db), repos.NewMySQLTaskLeaseRepository(db)),
 	}
 }
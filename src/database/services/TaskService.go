@@ -0,0 +1,48 @@
+package services
+
+import (
+	"time"
+	"visual-feed-aggregator/src/database/models"
+)
+
+type taskService struct {
+	taskRunRepo   models.TaskRunRepository
+	taskLeaseRepo models.TaskLeaseRepository
+}
+
+// NewTaskService creates a new task service with the necessary repositories
+func NewTaskService(taskRunRepo models.TaskRunRepository, taskLeaseRepo models.TaskLeaseRepository) TaskService {
+	return &taskService{taskRunRepo: taskRunRepo, taskLeaseRepo: taskLeaseRepo}
+}
+
+func (s *taskService) CreateTaskRun(run *models.TaskRun) error {
+	return s.taskRunRepo.CreateTaskRun(run)
+}
+
+func (s *taskService) FindRecentTaskRuns(limit int) ([]models.TaskRun, error) {
+	return s.taskRunRepo.FindRecentTaskRuns(limit)
+}
+
+func (s *taskService) FindLastTaskRuns(changedContent bool) ([]models.TaskRun, error) {
+	return s.taskRunRepo.FindLastTaskRuns(changedContent)
+}
+
+func (s *taskService) CleanupOldTaskRuns(before time.Time) (int64, error) {
+	return s.taskRunRepo.CleanupOldTaskRuns(before)
+}
+
+func (s *taskService) AcquireTaskLease(task, owner string, ttl time.Duration) (bool, error) {
+	return s.taskLeaseRepo.AcquireTaskLease(task, owner, ttl)
+}
+
+func (s *taskService) RenewTaskLeases(owner string, ttl time.Duration) error {
+	return s.taskLeaseRepo.RenewTaskLeases(owner, ttl)
+}
+
+func (s *taskService) ReleaseTaskLeases(owner string) error {
+	return s.taskLeaseRepo.ReleaseTaskLeases(owner)
+}
+
+func (s *taskService) FindTaskLeases() ([]models.TaskLease, error) {
+	return s.taskLeaseRepo.FindTaskLeases()
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"database/sql"
+	"errors"
+	"time"
 	"visual-feed-aggregator/src/database/models"
 )
 
@@ -34,3 +37,14 @@ func (s *userService) CreateUserIfNotExists(email, pictureURL string) (models.Us
 	err = s.userRepo.CreateUser(&user)
 	return user, err == nil, err
 }
+
+func (s *userService) SetNsfwMode(user models.User, nsfwMode string) error {
+	switch nsfwMode {
+	case models.NsfwHide, models.NsfwBlur, models.NsfwShow:
+	default:
+		return errors.New("unknown nsfw mode " + nsfwMode)
+	}
+	user.NsfwMode = nsfwMode
+	user.SettingsUpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	return s.userRepo.UpdateUser(user)
+}
@@ -9,10 +9,11 @@ import (
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 	"visual-feed-aggregator/src/database"
-	"visual-feed-aggregator/src/database/models"
 	"visual-feed-aggregator/src/database/services"
+	"visual-feed-aggregator/src/providers"
 	"visual-feed-aggregator/src/server"
 	"visual-feed-aggregator/src/server/pages"
 	"visual-feed-aggregator/src/tasks"
@@ -37,6 +38,13 @@ var envVars = []struct {
 }{
 	{"TITLE", "vifa"},
 	{"REFRESH_RATE_MINUTES", "60"},
+	{"TASK_SCHEDULES", ""},
+	{"POLL_MIN_MINUTES", "5"},
+	{"POLL_MAX_MINUTES", "360"},
+	{"FETCH_WORKERS", "8"},
+	{"HOST_CONCURRENCY", "4"},
+	{"HOST_REQUESTS_PER_SECOND", "2"},
+	{"HOST_LIMITS", ""},
 	{"CUTOFF_DAYS", "7"},
 	{"LOG_LEVEL", "INFO"},
 	{"PORT", ""},
@@ -47,21 +55,36 @@ var envVars = []struct {
 	{"DB_ADDRESS", ""},
 	{"GOOGLE_OAUTH2_CLIENT_ID", ""},
 	{"GOOGLE_OAUTH2_CLIENT_SECRET", ""},
+	{"TWITCH_CLIENT_ID", ""},
+	{"TWITCH_CLIENT_SECRET", ""},
+	{"YOUTUBE_API_KEY", ""},
+	{"YOUTUBE_API_DAILY_QUOTA", "10000"},
+	{"REDDIT_CLIENT_ID", ""},
+	{"REDDIT_CLIENT_SECRET", ""},
+	{"REDDIT_USER_AGENT", "web:visual-feed-aggregator:v1.0"},
+	{"INSTAGRAM_DAILY_REQUESTS", "50"},
+	{"INSTAGRAM_SESSION_ID", ""},
+	{"ADMIN_EMAILS", ""},
+	{"MIRRORS_TWITTER", ""},
+	{"MIRRORS_YOUTUBE", ""},
+	{"MIRRORS_REDDIT", ""},
 }
 
-var backgroundTasks []tasks.BackgroundTask = []tasks.BackgroundTask{
-	tasks.YoutubeBackgroundTask,
-	tasks.RedditBackgroundTask,
-	tasks.TwitterBackgroundTask,
-	// tasks.InstagramBackgroundTask, // TODO disabled due to the public insta api being limited to a few requests/day
-	tasks.CleanupBackgroundTask,
-}
-
-var backgroundTasksLastRun map[string]time.Time = map[string]time.Time{
-	models.KindYoutube: time.Now().UTC(),
-	models.KindReddit:  time.Now().UTC(),
-	models.KindTwitter: time.Now().UTC(),
-	// models.KindInstagram: time.Now().UTC(),
+// registeredProviders are all the enabled social media kinds, in the order they appear in the sidebar
+var registeredProviders []providers.Provider = []providers.Provider{
+	providers.Youtube{},
+	providers.Reddit{},
+	providers.Lemmy{},
+	providers.HackerNews{},
+	providers.Lobsters{},
+	providers.Twitter{},
+	providers.Feed{},
+	providers.Mastodon{},
+	providers.Bluesky{},
+	providers.PeerTube{},
+	providers.Odysee{},
+	providers.Podcast{},
+	providers.Github{},
 }
 
 func main() {
@@ -76,11 +99,25 @@ func main() {
 		logging.Fatalln("Could not instantiate session store")
 	}
 	services := services.NewMySQLServiceCollection(db)
+	configureMirrors(env)
+	configureYoutubeAPI(env)
+	if providers.ConfigureRedditOAuth(env["REDDIT_CLIENT_ID"], env["REDDIT_CLIENT_SECRET"], env["REDDIT_USER_AGENT"]) {
+		logging.Println(logging.Info, "reddit is fetched via its official api")
+	}
+	registerProviders(append(registeredProviders, configuredProviders(env)...))
+
+	cutoffDays, err := strconv.ParseInt(env["CUTOFF_DAYS"], 10, 64)
+	if err != nil {
+		cutoffDays = defaultCutoffDays
+	}
+	configurePolling(env)
+	configureFetching(env) // before the server, refreshes start the fetch workers
+	scheduler := tasks.NewScheduler(db, &services, cutoffDays)
 
 	// server
 	srv := server.NewServer(db, &services, sessionStore, oauth2Config(env), env)
 	router := httprouter.New()
-	pages.SetupRoutes(srv, router, getBackgroundTaskLastRun)
+	pages.SetupRoutes(srv, router, scheduler.Snapshot, scheduler.Refresh, scheduler.Status)
 	pages.RedirectTLS(env["PORT"])
 	go func() {
 		if err := srv.Run(router); err != http.ErrServerClosed {
@@ -89,24 +126,13 @@ func main() {
 	}()
 
 	// background tasks
-	cutoffDays, err := strconv.ParseInt(env["CUTOFF_DAYS"], 10, 64)
-	if err != nil {
-		cutoffDays = defaultCutoffDays
-	}
-	refreshRateMinutes, err := strconv.ParseInt(env["REFRESH_RATE_MINUTES"], 10, 64)
-	if err != nil {
-		refreshRateMinutes = defaultRefreshRateMinutes
-	}
-	stopSignal := make(chan bool, len(backgroundTasks))
-	startBackgroundTasks(backgroundTasks, backgroundTasksLastRun, stopSignal, db, &services, cutoffDays, refreshRateMinutes)
+	scheduler.Start(backgroundTasks(env))
 
 	// graceful exit
 	osSignalExit := make(chan os.Signal, 1)
 	signal.Notify(osSignalExit, os.Interrupt, os.Kill)
 	<-osSignalExit
-	for i := 0; i < len(backgroundTasks); i++ {
-		stopSignal <- true
-	}
+	scheduler.Stop(5 * time.Second)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Stop(ctx); err != nil {
@@ -156,14 +182,129 @@ func oauth2Config(env map[string]string) oauth2.Config {
 	}
 }
 
-func getBackgroundTaskLastRun(kind string) time.Time {
-	return backgroundTasksLastRun[kind]
+// configuredProviders are the social media kinds, which are only enabled if their credentials (or request budget) are set up
+func configuredProviders(env map[string]string) []providers.Provider {
+	ret := []providers.Provider{}
+	if twitch := providers.NewTwitch(env["TWITCH_CLIENT_ID"], env["TWITCH_CLIENT_SECRET"]); twitch != nil {
+		ret = append(ret, twitch)
+	}
+	dailyRequests, err := strconv.Atoi(env["INSTAGRAM_DAILY_REQUESTS"])
+	if err != nil {
+		logging.Println(logging.Error, "invalid INSTAGRAM_DAILY_REQUESTS:", err)
+	}
+	if instagram := providers.NewInstagram(dailyRequests, env["INSTAGRAM_SESSION_ID"]); instagram != nil {
+		ret = append(ret, instagram)
+	}
+	return ret
+}
+
+// configurePolling bounds the polling intervals of the channels, which adapt to their posting frequency
+func configurePolling(env map[string]string) {
+	minMinutes, err := strconv.ParseInt(env["POLL_MIN_MINUTES"], 10, 64)
+	if err != nil {
+		logging.Println(logging.Error, "invalid POLL_MIN_MINUTES:", err)
+		return
+	}
+	maxMinutes, err := strconv.ParseInt(env["POLL_MAX_MINUTES"], 10, 64)
+	if err != nil {
+		logging.Println(logging.Error, "invalid POLL_MAX_MINUTES:", err)
+		return
+	}
+	tasks.SetPollingBounds(time.Duration(minMinutes)*time.Minute, time.Duration(maxMinutes)*time.Minute)
+}
+
+// configureFetching sizes the shared pool of fetch workers & limits the requests per upstream host
+func configureFetching(env map[string]string) {
+	workers, err := strconv.Atoi(env["FETCH_WORKERS"])
+	if err != nil {
+		logging.Println(logging.Error, "invalid FETCH_WORKERS:", err)
+	} else {
+		tasks.SetFetchWorkers(workers)
+	}
+	concurrency, err := strconv.Atoi(env["HOST_CONCURRENCY"])
+	if err != nil {
+		logging.Println(logging.Error, "invalid HOST_CONCURRENCY:", err)
+		return
+	}
+	rate, err := strconv.ParseFloat(env["HOST_REQUESTS_PER_SECOND"], 64)
+	if err != nil {
+		logging.Println(logging.Error, "invalid HOST_REQUESTS_PER_SECOND:", err)
+		return
+	}
+	defaults := providers.HostLimit{Concurrency: concurrency, Rate: rate, Burst: concurrency}
+	if err := providers.ConfigureHostLimits(defaults, env["HOST_LIMITS"]); err != nil {
+		logging.Println(logging.Error, "invalid HOST_LIMITS:", err)
+	}
+}
+
+// configureMirrors replaces the default mirrors of a kind with the comma separated hosts of MIRRORS_<KIND>
+func configureMirrors(env map[string]string) {
+	for _, kind := range providers.MirrorKinds() {
+		if hosts := env["MIRRORS_"+strings.ToUpper(kind)]; hosts != "" {
+			providers.SetMirrors(kind, strings.Split(hosts, ","))
+		}
+	}
 }
 
-func startBackgroundTasks(tasks []tasks.BackgroundTask, lastRun map[string]time.Time,
-	stopSignal <-chan bool, db *sqlx.DB, services *services.ServiceCollection, cutoffDays, refreshRateMinutes int64) {
+// configureYoutubeAPI enables the youtube data api, if YOUTUBE_API_KEY is set
+func configureYoutubeAPI(env map[string]string) {
+	quota, err := strconv.Atoi(env["YOUTUBE_API_DAILY_QUOTA"])
+	if err != nil {
+		logging.Println(logging.Error, "invalid YOUTUBE_API_DAILY_QUOTA:", err)
+		return
+	}
+	if providers.ConfigureYoutubeAPI(env["YOUTUBE_API_KEY"], quota) {
+		logging.Println(logging.Info, "youtube is fetched via the youtube data api, with a daily quota of", quota, "units")
+	}
+}
 
-	for _, task := range tasks {
-		go task(stopSignal, lastRun, db, services, cutoffDays, refreshRateMinutes)
+func registerProviders(provs []providers.Provider) {
+	for _, p := range provs {
+		providers.Register(p)
 	}
 }
+
+// backgroundTasks creates the channel tasks of the registered providers, the cleanup & the mirror checks.
+// their default schedules can be replaced by TASK_SCHEDULES, i.e. "cleanup=0 3 * * *;reddit=@every 5m"
+func backgroundTasks(env map[string]string) []tasks.Task {
+	refreshRateMinutes, err := strconv.ParseInt(env["REFRESH_RATE_MINUTES"], 10, 64)
+	if err != nil {
+		refreshRateMinutes = defaultRefreshRateMinutes
+	}
+	if os.Getenv("REFRESH_RATE_MINUTES") != "" { // it used to be the interval of the fetches
+		logging.Println(logging.Warn, "REFRESH_RATE_MINUTES is deprecated, it only sets the interval of the cleanup."+
+			" the channels are polled on their own schedules (POLL_MIN_MINUTES & POLL_MAX_MINUTES), the cleanup can be scheduled via TASK_SCHEDULES")
+	}
+	schedules := map[string]tasks.Schedule{}
+	for _, entry := range strings.Split(env["TASK_SCHEDULES"], ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		split := strings.SplitN(entry, "=", 2)
+		if len(split) != 2 {
+			logging.Println(logging.Error, "invalid TASK_SCHEDULES entry", entry, "- expected task=schedule")
+			continue
+		}
+		schedule, err := tasks.ParseSchedule(split[1])
+		if err != nil {
+			logging.Println(logging.Error, "invalid TASK_SCHEDULES entry:", err)
+			continue
+		}
+		schedules[strings.TrimSpace(split[0])] = schedule
+	}
+	scheduleOf := func(name string, defaultSchedule tasks.Schedule) tasks.Schedule {
+		if schedule, ok := schedules[name]; ok {
+			return schedule
+		}
+		return defaultSchedule
+	}
+
+	ret := []tasks.Task{
+		tasks.CleanupTask(scheduleOf("cleanup", tasks.Every(time.Duration(refreshRateMinutes)*time.Minute))),
+		tasks.MirrorHealthTask(scheduleOf("mirrors", tasks.DefaultMirrorSchedule)),
+	}
+	for _, p := range providers.All() {
+		ret = append(ret, tasks.ChannelTask(p, scheduleOf(p.Kind(), tasks.DefaultChannelSchedule)))
+	}
+	return ret
+}
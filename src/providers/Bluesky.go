@@ -0,0 +1,264 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// blueskyAppView is the public, unauthenticated AppView of the bluesky network
+const blueskyAppView = "https://public.api.bsky.app/xrpc/"
+
+// Bluesky provides accounts of the AT protocol network via the public AppView
+type Bluesky struct{}
+
+// Kind ...
+func (Bluesky) Kind() string {
+	return models.KindBluesky
+}
+
+// Icon ...
+func (Bluesky) Icon() string {
+	return "M12 10.8c-1.087-2.114-4.046-6.053-6.798-7.995C2.566.944 1.561 1.266.902 1.565.139 1.908 0 3.08 0 3.768c0 .69.378 5.65.624 6.479.815 2.736 3.713 3.66 6.383 3.364.136-.02.275-.039.415-.056-.138.022-.276.04-.415.056-3.912.58-7.387 2.005-2.83 7.078 5.013 5.19 6.87-1.113 7.823-4.308.953 3.195 2.05 9.271 7.733 4.308 4.267-4.308 1.172-6.498-2.74-7.078a8.741 8.741 0 0 1-.415-.056c.14.017.279.036.415.056 2.67.297 5.568-.628 6.383-3.364.246-.828.624-5.79.624-6.478 0-.69-.139-1.861-.902-2.206-.659-.298-1.664-.62-4.3 1.24C16.046 4.748 13.087 8.687 12 10.8Z"
+}
+
+// SettingsHint ...
+func (Bluesky) SettingsHint() string {
+	return "examples: \n  @bsky.app \n  https://bsky.app/profile/bsky.app \n  https://bsky.app/profile/bsky.app?reposts (reposts are included)"
+}
+
+// ChannelURL ...
+func (Bluesky) ChannelURL(externalID string) string {
+	did, _ := trimChannelOption(externalID, "reposts")
+	return "https://bsky.app/profile/" + did
+}
+
+// ContentURL ...
+func (Bluesky) ContentURL(externalID string) string {
+	return "https://bsky.app/profile/" + externalID // i.e. "did:plc:z72i7hdynmk6r22z27h6tvur/post/3l6oveex3ii2l"
+}
+
+// ValidateChannel ...
+func (Bluesky) ValidateChannel(data string) bool {
+	return queryBlueskyProfile(data) != nil
+}
+
+// MetaData ...
+func (Bluesky) MetaData(channelID string) (string, string, string, string) {
+	profile := queryBlueskyProfile(channelID)
+	if profile == nil {
+		return "", "", "", ""
+	}
+	author := profile.DisplayName
+	if author == "" {
+		author = profile.Handle
+	}
+	externalID := profile.DID
+	if profile.reposts {
+		externalID += "?reposts"
+	}
+
+	return author, models.KindBluesky, profile.Avatar, externalID
+}
+
+type blueskyFeedItem struct {
+	Post   blueskyPost
+	Reason *struct {
+		Type      string `json:"$type"`
+		IndexedAt time.Time
+	}
+}
+
+// Fetch pages backward (via cursor) until the high-water mark or the cutoff is reached
+func (Bluesky) Fetch(job *Job) error {
+	did, reposts := trimChannelOption(job.Channel.ExternalID, "reposts")
+	query := url.Values{}
+	query.Set("actor", did)
+	query.Set("filter", "posts_no_replies")
+	query.Set("limit", "50")
+	for page := 0; page < listingMaxPages; page++ {
+		body, err := httpGetBody(blueskyAppView + "app.bsky.feed.getAuthorFeed?" + query.Encode())
+		if err != nil {
+			return err
+		}
+
+		var f struct {
+			Cursor string
+			Feed   []blueskyFeedItem
+		}
+		err = json.Unmarshal(body, &f)
+		if err != nil {
+			return err
+		}
+		if storeBlueskyFeed(job, f.Feed, reposts) || f.Cursor == "" {
+			break
+		}
+		query.Set("cursor", f.Cursor)
+	}
+	return nil
+}
+
+// storeBlueskyFeed returns true, if the high-water mark or the cutoff was reached
+func storeBlueskyFeed(job *Job, items []blueskyFeedItem, reposts bool) bool {
+	reachedEnd := len(items) == 0
+	for _, item := range items {
+		post := item.Post
+		title := post.Record.Text
+		date := post.Record.CreatedAt
+		if item.Reason != nil {
+			if !reposts || item.Reason.Type != "app.bsky.feed.defs#reasonRepost" {
+				continue
+			}
+			title = "reposted @" + post.Author.Handle + ": " + title
+			date = item.Reason.IndexedAt
+		}
+		rkey := post.URI[strings.LastIndex(post.URI, "/")+1:]
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = date.In(job.Loc)
+		content.ExternalID = post.Author.DID + "/post/" + rkey
+		content.Title = title
+
+		if content.Date.Before(job.DateCutoff) {
+			reachedEnd = true
+			continue
+		}
+		if rkey == "" {
+			continue
+		}
+
+		embed := post.Embed
+		if embed != nil && embed.Media != nil { // quote posts with media
+			embed = embed.Media
+		}
+		if embed != nil && embed.External != nil && embed.External.Title != "" {
+			if content.Title != "" {
+				content.Title += "\n"
+			}
+			content.Title += embed.External.Title
+		}
+
+		err := createContent(job, &content)
+		if err == errKnownContent {
+			reachedEnd = true
+		}
+		if err != nil {
+			continue
+		}
+
+		if embed == nil {
+			continue
+		}
+		for _, img := range embed.Images {
+			var media models.Media
+			media.ContentID = content.ID
+			media.URL = img.Fullsize
+			if media.URL == "" {
+				media.URL = img.Thumb
+			}
+			if img.Alt != "" {
+				media.Alt.String = img.Alt
+				media.Alt.Valid = true
+			}
+			storeMedia(job, &media)
+		}
+		if embed.Thumbnail != "" { // videos
+			addMedia(job, content.ID, embed.Thumbnail)
+		}
+		if embed.External != nil && embed.External.Thumb != "" { // link cards
+			addMedia(job, content.ID, embed.External.Thumb)
+		}
+	}
+	return reachedEnd
+}
+
+type blueskyProfile struct {
+	DID         string
+	Handle      string
+	DisplayName string
+	Avatar      string
+
+	reposts bool
+}
+
+type blueskyEmbed struct {
+	Images []struct {
+		Thumb    string
+		Fullsize string
+		Alt      string
+	}
+	External *struct {
+		URI   string
+		Title string
+		Thumb string
+	}
+	Thumbnail string
+	Media     *blueskyEmbed
+}
+
+type blueskyPost struct {
+	URI    string
+	Author blueskyProfile
+	Record struct {
+		Text      string
+		CreatedAt time.Time
+	}
+	Embed *blueskyEmbed
+}
+
+// i.e. "@bsky.app", "did:plc:z72i7hdynmk6r22z27h6tvur", "https://bsky.app/profile/bsky.app"
+var blueskyActorRegEx = regexp.MustCompile(`^(?:https?:\/\/(?:www\.)?bsky\.app\/profile\/)?@?(did:[a-z]+:[A-Za-z0-9._:%-]+|[A-Za-z0-9.-]+\.[A-Za-z]+)\/?$`)
+
+// queryBlueskyProfile resolves a handle, did or profile url (optionally suffixed with "?reposts" or " +reposts") to its profile
+func queryBlueskyProfile(data string) *blueskyProfile {
+	data, reposts := trimChannelOption(data, "reposts")
+	res := blueskyActorRegEx.FindStringSubmatch(data)
+	if res == nil {
+		return nil
+	}
+
+	did, err := resolveBlueskyDID(res[1])
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	body, err := httpGetBody(blueskyAppView + "app.bsky.actor.getProfile?actor=" + url.QueryEscape(did))
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	var profile blueskyProfile
+	if err := json.Unmarshal(body, &profile); err != nil || profile.DID == "" {
+		logging.Println(logging.Info, "bluesky profile lookup failed for", did, err)
+		return nil
+	}
+	profile.reposts = reposts
+	return &profile
+}
+
+// resolveBlueskyDID resolves a handle to its decentralized identifier, which stays the same even if the handle changes
+func resolveBlueskyDID(actor string) (string, error) {
+	if strings.HasPrefix(actor, "did:") {
+		return actor, nil
+	}
+	body, err := httpGetBody(blueskyAppView + "com.atproto.identity.resolveHandle?handle=" + url.QueryEscape(strings.ToLower(actor)))
+	if err != nil {
+		return "", err
+	}
+	var resolved struct {
+		DID string
+	}
+	if err := json.Unmarshal(body, &resolved); err != nil {
+		return "", err
+	}
+	if resolved.DID == "" {
+		return "", errors.New("could not resolve bluesky handle " + actor)
+	}
+	return resolved.DID, nil
+}
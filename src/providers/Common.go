@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// ErrNotModified is returned by conditional requests & thus by Fetch, if the channel didn't change since its last fetch
+var ErrNotModified = errors.New("not modified")
+
+var httpClient = newHTTPClient()
+
+// HTTPError is returned for unsuccessful responses, it keeps the status code for the health of the channels
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return e.Status
+}
+
+// HTTPStatus returns the status code of a failed request, 0 if the error isn't a http error
+func HTTPStatus(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// errKnownContent is returned for content, which is older than the high-water mark of its channel
+var errKnownContent = errors.New("content is already known")
+
+// listingMaxPages limits the pagination of listings, which are paged backward until the high-water mark or the cutoff is reached
+const listingMaxPages = 5
+
+// createContent stores the content of a listing, which is sorted by date.
+// content older than the high-water mark of the channel isn't inserted again, errKnownContent is returned instead
+func createContent(job *Job, content *models.Content) error {
+	job.see(content)
+	if job.known(content) {
+		return errKnownContent
+	}
+	return job.Services.ContentService.CreateContent(content)
+}
+
+// getChannel requests a page of the listing of a channel. the first page is requested with the validators of the last fetch,
+// ErrNotModified is returned if it didn't change since
+func getChannel(job *Job, url string, page int) ([]byte, error) {
+	if page > 0 {
+		return httpGetBody(url)
+	}
+	body, header, err := httpGetWith(httpClient, url, job.conditional())
+	if err == nil {
+		job.keepValidators(header)
+	}
+	return body, err
+}
+
+// mirrorGetChannel is getChannel for the mirrors of a kind, it returns the response headers & the host of the mirror as well
+func mirrorGetChannel(job *Job, kind, path string, page int) ([]byte, http.Header, string, error) {
+	if page > 0 {
+		return mirrorGetWith(kind, path, nil)
+	}
+	body, header, host, err := mirrorGetWith(kind, path, job.conditional())
+	if err == nil {
+		job.keepValidators(header)
+	}
+	return body, header, host, err
+}
+
+// httpRequest issues a request without a body, it's subject to the host limits like all requests of the providers
+func httpRequest(method, url string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", util.UserAgent)
+	return httpClient.Do(req)
+}
+
+func httpCanGet(method, url string) error {
+	resp, err := httpRequest(method, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return &HTTPError{StatusCode: resp.StatusCode, Status: http.StatusText(resp.StatusCode)}
+	}
+	return nil
+}
+
+// httpGetBody issues a GET request and returns the body of a successful response
+func httpGetBody(url string) ([]byte, error) {
+	resp, err := httpRequest("GET", url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// httpGetBodyWith issues a GET request via a custom client (i.e. an oauth2 client) with additional headers
+func httpGetBodyWith(client *http.Client, url string, header http.Header) ([]byte, error) {
+	body, _, err := httpGetWith(client, url, header)
+	return body, err
+}
+
+// httpGetWith is httpGetBodyWith, which returns the response headers as well (i.e. pagination cursors)
+func httpGetWith(client *http.Client, url string, header http.Header) ([]byte, http.Header, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", util.UserAgent)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.Header, err
+}
+
+// joinErrors combines the errors of the parts of a fetch, nil if all parts succeeded.
+// the first error is wrapped, so it keeps its http status
+func joinErrors(errs ...error) error {
+	var first error
+	rest := []string{}
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case first == nil:
+			first = err
+		default:
+			rest = append(rest, err.Error())
+		}
+	}
+	if first == nil || len(rest) == 0 {
+		return first
+	}
+	return fmt.Errorf("%w; %s", first, strings.Join(rest, "; "))
+}
+
+// trimChannelOption removes an option suffix (i.e. "?boosts" or " +boosts") from the user input of a channel
+func trimChannelOption(data, option string) (string, bool) {
+	data = strings.TrimSpace(data)
+	for _, suffix := range []string{"?" + option, "+" + option} {
+		if strings.HasSuffix(data, suffix) {
+			return strings.TrimSpace(strings.TrimSuffix(data, suffix)), true
+		}
+	}
+	return data, false
+}
+
+func hasImageExtension(url string) bool {
+	switch filepath.Ext(url) {
+	case ".png", ".jpeg", ".jpg", ".gif":
+		return true
+	}
+	return false
+}
+
+// addMedia links a media url to an already stored content
+func addMedia(job *Job, contentID int64, url string) {
+	storeMedia(job, &models.Media{ContentID: contentID, URL: url})
+}
+
+// storeMedia stores a fully populated media, untyped media are images
+func storeMedia(job *Job, media *models.Media) {
+	if media.Type == "" {
+		media.Type = models.MediaImage
+	}
+	if err := job.Services.MediaService.CreateMedia(media); err != nil {
+		logging.Println(logging.Error, err)
+	}
+}
+
+// storeStory stores a story of a link aggregator along with the og:image of the linked page.
+// stories, which are already stored, get their score & comment count updated
+func storeStory(job *Job, content *models.Content, storyURL string) {
+	if content.Date.Before(job.DateCutoff) || len(content.ExternalID) > 255 {
+		return
+	}
+	if err := job.Services.ContentService.CreateContent(content); err != nil { // content already exists (most likely)
+		if err := job.Services.ContentService.UpdateContentStats(*content); err != nil {
+			logging.Println(logging.Error, err)
+		}
+		return
+	}
+	if og := queryOpenGraphImage(storyURL); og != "" {
+		addMedia(job, content.ID, og)
+	}
+}
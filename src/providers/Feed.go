@@ -0,0 +1,195 @@
+package providers
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// feedMaxOpenGraphLookups limits the linked pages, which are loaded per fetch for their og:image, i.e. on the first fetch of a feed
+const feedMaxOpenGraphLookups = 5
+
+// Feed provides generic rss 2.0, atom 1.0 and json feed 1.1 feeds
+type Feed struct{}
+
+// Kind ...
+func (Feed) Kind() string {
+	return models.KindFeed
+}
+
+// Icon ...
+func (Feed) Icon() string {
+	return "M19.199 24C19.199 13.467 10.533 4.8 0 4.8V0c13.165 0 24 10.835 24 24h-4.801zM3.291 17.415c1.814 0 3.293 1.479 3.293 3.295 0 1.813-1.485 3.29-3.301 3.29C1.47 24 0 22.526 0 20.71s1.475-3.294 3.291-3.295zM15.909 24h-4.665c0-6.169-5.075-11.245-11.244-11.245V8.09c8.727 0 15.909 7.184 15.909 15.91z"
+}
+
+// SettingsHint ...
+func (Feed) SettingsHint() string {
+	return "examples: \n  https://go.dev/blog/feed.atom \n  https://go.dev/blog (the feed gets discovered automatically)"
+}
+
+// SupportsOpml feed readers export their subscriptions as opml
+func (Feed) SupportsOpml() bool {
+	return true
+}
+
+// ChannelURL ...
+func (Feed) ChannelURL(externalID string) string {
+	return externalID // feed url
+}
+
+// ContentURL ...
+func (Feed) ContentURL(externalID string) string {
+	return externalID // link of the item
+}
+
+// ValidateChannel ...
+func (Feed) ValidateChannel(data string) bool {
+	feedURL, _ := resolveFeed(data)
+	return feedURL != ""
+}
+
+// MetaData ...
+func (Feed) MetaData(channelID string) (string, string, string, string) {
+	feedURL, f := resolveFeed(channelID)
+	if feedURL == "" {
+		return "", "", "", ""
+	}
+	author := f.Title
+	if author == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			author = u.Host
+		}
+	}
+	profilePic := ""
+	if f.Image != "" {
+		profilePic = resolveURL(feedURL, f.Image)
+	}
+
+	return author, models.KindFeed, profilePic, feedURL
+}
+
+// Fetch ...
+func (Feed) Fetch(job *Job) error {
+	body, err := getChannel(job, job.Channel.ExternalID, 0)
+	if err != nil {
+		return err
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		return err
+	}
+	storeFeedItems(job, f, job.Channel.ExternalID)
+	return nil
+}
+
+// resolveFeed returns the url & content of the feed the user input refers to.
+// the input can either be the feed itself or a html page, which advertises its feeds via <link rel="alternate">
+func resolveFeed(data string) (string, *parsedFeed) {
+	pageURL := strings.TrimSpace(data)
+	if pageURL == "" {
+		return "", nil
+	}
+	if !strings.Contains(pageURL, "://") {
+		pageURL = "https://" + pageURL
+	}
+	body, err := httpGetBody(pageURL)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return "", nil
+	}
+	candidates := []string{}
+	if f, err := parseFeed(body); err == nil {
+		if len(pageURL) <= 255 {
+			return pageURL, f
+		}
+	} else {
+		candidates = discoverFeedURLs(pageURL, body)
+	}
+	for _, candidate := range candidates {
+		if len(candidate) > 255 { // external_id limit
+			continue
+		}
+		body, err := httpGetBody(candidate)
+		if err != nil {
+			logging.Println(logging.Info, err)
+			continue
+		}
+		if f, err := parseFeed(body); err == nil {
+			return candidate, f
+		}
+	}
+	return "", nil
+}
+
+// storeFeedItems stores all the new items of a feed as the channel's content, relative links are resolved against the feed url
+func storeFeedItems(job *Job, f *parsedFeed, feedURL string) {
+	lookups := feedMaxOpenGraphLookups
+	for idx, item := range f.Items {
+		if idx >= 50 {
+			break
+		}
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Title = item.Title
+		content.ExternalID = item.Link
+		if content.ExternalID == "" {
+			content.ExternalID = item.ID
+		}
+		if content.ExternalID == "" {
+			continue
+		}
+		content.ExternalID = resolveURL(feedURL, content.ExternalID)
+		if item.Date.IsZero() { // undated items are dated by their first appearance
+			content.Date = time.Now().In(job.Loc)
+		} else {
+			content.Date = item.Date.In(job.Loc)
+		}
+		if content.Date.Before(job.DateCutoff) { // items are not necessarily sorted
+			continue
+		}
+
+		err := job.Services.ContentService.CreateContent(&content)
+		if err != nil { // content already exists (most likely)
+			continue
+		}
+
+		for _, img := range feedItemImages(item, content.ExternalID, &lookups) {
+			addMedia(job, content.ID, img)
+		}
+	}
+}
+
+// feedItemImages collects the images of a feed item, falling back to the
+// images of its description and finally to the og:image of the linked page, as long as there are lookups left
+func feedItemImages(item parsedFeedItem, link string, lookups *int) []string {
+	images := append([]string{}, item.Images...)
+	for _, enc := range item.Enclosures {
+		if strings.HasPrefix(enc.Type, "image/") || (enc.Type == "" && hasImageExtension(enc.URL)) {
+			images = append(images, enc.URL)
+		}
+	}
+	if len(images) == 0 {
+		if imgs := htmlImages(link, item.Description); len(imgs) > 0 {
+			images = append(images, imgs[0])
+		}
+	}
+	if len(images) == 0 && *lookups > 0 {
+		*lookups--
+		if og := queryOpenGraphImage(link); og != "" {
+			images = append(images, og)
+		}
+	}
+
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, img := range images {
+		img = resolveURL(link, img)
+		if !seen[img] {
+			seen[img] = true
+			ret = append(ret, img)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,416 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// parsedFeed is the common representation of rss 2.0, atom 1.0 and json feed 1.1 documents
+type parsedFeed struct {
+	Title string
+	Link  string
+	Image string
+	Items []parsedFeedItem
+}
+
+type parsedFeedItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Date        time.Time
+	Description string
+	Images      []string
+	Enclosures  []parsedFeedEnclosure
+}
+
+type parsedFeedEnclosure struct {
+	URL      string
+	Type     string
+	Length   int64
+	Duration int64 // in seconds, 0 if unknown
+}
+
+const nsAtom = "http://www.w3.org/2005/Atom"
+
+var errUnknownFeedFormat = errors.New("unknown feed format")
+
+// parseFeed detects the format of the document and parses it
+func parseFeed(body []byte) (*parsedFeed, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return nil, errUnknownFeedFormat
+	}
+	if trimmed[0] == '{' {
+		return parseJSONFeed(trimmed)
+	}
+	return parseXMLFeed(trimmed)
+}
+
+type feedMediaElement struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+type feedMediaGroup struct {
+	Thumbnails []feedMediaElement `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Contents   []feedMediaElement `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+type feedLink struct {
+	XMLName xml.Name
+	Href    string `xml:"href,attr"`
+	Rel     string `xml:"rel,attr"`
+	Type    string `xml:"type,attr"`
+	Length  int64  `xml:"length,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title          string             `xml:"title"`
+	Links          []feedLink         `xml:"link"`
+	GUID           string             `xml:"guid"`
+	PubDate        string             `xml:"pubDate"`
+	DCDate         string             `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description    string             `xml:"description"`
+	Content        string             `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Enclosures     []rssEnclosure     `xml:"enclosure"`
+	Thumbnails     []feedMediaElement `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Contents       []feedMediaElement `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroup     feedMediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+	ItunesImage    itunesImage        `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ItunesDuration string             `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+}
+
+type rssImage struct {
+	URL string `xml:"url"`
+}
+
+type rssChannel struct {
+	Title       string      `xml:"title"`
+	Links       []feedLink  `xml:"link"`
+	ItunesImage itunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"` // has to precede <image>, which matches any namespace
+	Image       rssImage    `xml:"image"`
+	Items       []rssItem   `xml:"item"`
+}
+
+type atomEntry struct {
+	ID         string             `xml:"http://www.w3.org/2005/Atom id"`
+	Title      string             `xml:"http://www.w3.org/2005/Atom title"`
+	Links      []feedLink         `xml:"http://www.w3.org/2005/Atom link"`
+	Published  string             `xml:"http://www.w3.org/2005/Atom published"`
+	Updated    string             `xml:"http://www.w3.org/2005/Atom updated"`
+	Summary    string             `xml:"http://www.w3.org/2005/Atom summary"`
+	Content    string             `xml:"http://www.w3.org/2005/Atom content"`
+	Thumbnails []feedMediaElement `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroup feedMediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type xmlFeed struct {
+	XMLName xml.Name
+	// rss 2.0 (<rss><channel>) & rss 1.0 (<rdf:RDF><channel/><item/>)
+	Channel rssChannel `xml:"channel"`
+	Items   []rssItem  `xml:"item"`
+	// atom (<feed>)
+	Title   string      `xml:"http://www.w3.org/2005/Atom title"`
+	Links   []feedLink  `xml:"http://www.w3.org/2005/Atom link"`
+	Icon    string      `xml:"http://www.w3.org/2005/Atom icon"`
+	Logo    string      `xml:"http://www.w3.org/2005/Atom logo"`
+	Entries []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+func parseXMLFeed(body []byte) (*parsedFeed, error) {
+	var f xmlFeed
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel // non utf-8 feeds, i.e. latin-1 or shift_jis
+	if err := decoder.Decode(&f); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(f.XMLName.Local) {
+	case "rss", "rdf":
+		ret := &parsedFeed{
+			Title: strings.TrimSpace(f.Channel.Title),
+			Link:  rssLink(f.Channel.Links),
+			Image: strings.TrimSpace(f.Channel.Image.URL),
+		}
+		if ret.Image == "" {
+			ret.Image = strings.TrimSpace(f.Channel.ItunesImage.Href)
+		}
+		items := f.Channel.Items
+		if len(items) == 0 {
+			items = f.Items
+		}
+		for _, item := range items {
+			ret.Items = append(ret.Items, convertRSSItem(item))
+		}
+		return ret, nil
+	case "feed":
+		ret := &parsedFeed{
+			Title: strings.TrimSpace(f.Title),
+			Link:  atomLink(f.Links, "alternate"),
+			Image: strings.TrimSpace(f.Logo),
+		}
+		if ret.Image == "" {
+			ret.Image = strings.TrimSpace(f.Icon)
+		}
+		for _, entry := range f.Entries {
+			ret.Items = append(ret.Items, convertAtomEntry(entry))
+		}
+		return ret, nil
+	}
+	return nil, errUnknownFeedFormat
+}
+
+func convertRSSItem(item rssItem) parsedFeedItem {
+	ret := parsedFeedItem{
+		ID:          strings.TrimSpace(item.GUID),
+		Title:       strings.TrimSpace(html.UnescapeString(item.Title)),
+		Link:        rssLink(item.Links),
+		Description: item.Description,
+	}
+	if item.Content != "" {
+		ret.Description = item.Content
+	}
+	ret.Date, _ = parseFeedDate(item.PubDate)
+	if ret.Date.IsZero() {
+		ret.Date, _ = parseFeedDate(item.DCDate)
+	}
+	if ret.Link == "" && strings.HasPrefix(ret.ID, "http") {
+		ret.Link = ret.ID
+	}
+	duration := parseItunesDuration(item.ItunesDuration)
+	for _, enc := range item.Enclosures {
+		ret.Enclosures = append(ret.Enclosures, parsedFeedEnclosure{URL: strings.TrimSpace(enc.URL), Type: enc.Type, Length: enc.Length, Duration: duration})
+	}
+	ret.Images = mediaImages(append(item.Thumbnails, item.MediaGroup.Thumbnails...), append(item.Contents, item.MediaGroup.Contents...))
+	if href := strings.TrimSpace(item.ItunesImage.Href); href != "" {
+		ret.Images = append(ret.Images, href)
+	}
+	return ret
+}
+
+func convertAtomEntry(entry atomEntry) parsedFeedItem {
+	ret := parsedFeedItem{
+		ID:          strings.TrimSpace(entry.ID),
+		Title:       strings.TrimSpace(html.UnescapeString(entry.Title)),
+		Link:        atomLink(entry.Links, "alternate"),
+		Description: entry.Summary,
+	}
+	if entry.Content != "" {
+		ret.Description = entry.Content
+	}
+	ret.Date, _ = parseFeedDate(entry.Published)
+	if ret.Date.IsZero() {
+		ret.Date, _ = parseFeedDate(entry.Updated)
+	}
+	for _, l := range entry.Links {
+		if l.Rel == "enclosure" {
+			ret.Enclosures = append(ret.Enclosures, parsedFeedEnclosure{URL: strings.TrimSpace(l.Href), Type: l.Type, Length: l.Length})
+		}
+	}
+	ret.Images = mediaImages(append(entry.Thumbnails, entry.MediaGroup.Thumbnails...), entry.MediaGroup.Contents)
+	return ret
+}
+
+// rssLink returns the first plain rss <link>, ignoring <atom:link rel="self"> & co.
+func rssLink(links []feedLink) string {
+	for _, l := range links {
+		if l.XMLName.Space != nsAtom && strings.TrimSpace(l.Value) != "" {
+			return strings.TrimSpace(l.Value)
+		}
+	}
+	return ""
+}
+
+// atomLink returns the href of the first link with the given relation ("alternate" is the default relation)
+func atomLink(links []feedLink, rel string) string {
+	for _, l := range links {
+		r := l.Rel
+		if r == "" {
+			r = "alternate"
+		}
+		if r == rel && l.Href != "" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func mediaImages(thumbnails, contents []feedMediaElement) []string {
+	ret := []string{}
+	for _, t := range thumbnails {
+		if t.URL != "" {
+			ret = append(ret, strings.TrimSpace(t.URL))
+		}
+	}
+	for _, c := range contents {
+		if c.URL != "" && (c.Medium == "image" || strings.HasPrefix(c.Type, "image/")) {
+			ret = append(ret, strings.TrimSpace(c.URL))
+		}
+	}
+	return ret
+}
+
+func parseJSONFeed(body []byte) (*parsedFeed, error) {
+	type attachment struct {
+		URL               string  `json:"url"`
+		MimeType          string  `json:"mime_type"`
+		SizeInBytes       int64   `json:"size_in_bytes"`
+		DurationInSeconds float64 `json:"duration_in_seconds"`
+	}
+	type item struct {
+		ID            json.RawMessage `json:"id"`
+		URL           string          `json:"url"`
+		ExternalURL   string          `json:"external_url"`
+		Title         string          `json:"title"`
+		ContentHTML   string          `json:"content_html"`
+		ContentText   string          `json:"content_text"`
+		Summary       string          `json:"summary"`
+		Image         string          `json:"image"`
+		BannerImage   string          `json:"banner_image"`
+		DatePublished string          `json:"date_published"`
+		DateModified  string          `json:"date_modified"`
+		Attachments   []attachment    `json:"attachments"`
+	}
+	type feed struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url"`
+		Icon        string `json:"icon"`
+		Favicon     string `json:"favicon"`
+		Items       []item `json:"items"`
+	}
+	var f feed
+	if err := json.Unmarshal(body, &f); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(f.Version, "https://jsonfeed.org/version/") {
+		return nil, errUnknownFeedFormat
+	}
+
+	ret := &parsedFeed{
+		Title: f.Title,
+		Link:  f.HomePageURL,
+		Image: f.Icon,
+	}
+	if ret.Image == "" {
+		ret.Image = f.Favicon
+	}
+	for _, it := range f.Items {
+		pi := parsedFeedItem{
+			ID:          strings.Trim(string(it.ID), `"`), // ids are strings, but some feeds use numbers
+			Title:       it.Title,
+			Link:        it.URL,
+			Description: it.ContentHTML,
+		}
+		if pi.Link == "" {
+			pi.Link = it.ExternalURL
+		}
+		if pi.Description == "" {
+			pi.Description = it.ContentText
+		}
+		if pi.Title == "" {
+			pi.Title = it.Summary
+		}
+		if pi.Title == "" {
+			pi.Title = truncate(it.ContentText, 200)
+		}
+		pi.Date, _ = parseFeedDate(it.DatePublished)
+		if pi.Date.IsZero() {
+			pi.Date, _ = parseFeedDate(it.DateModified)
+		}
+		for _, img := range []string{it.Image, it.BannerImage} {
+			if img != "" {
+				pi.Images = append(pi.Images, img)
+			}
+		}
+		for _, a := range it.Attachments {
+			pi.Enclosures = append(pi.Enclosures, parsedFeedEnclosure{URL: a.URL, Type: a.MimeType, Length: a.SizeInBytes, Duration: int64(a.DurationInSeconds)})
+		}
+		ret.Items = append(ret.Items, pi)
+	}
+	return ret, nil
+}
+
+var feedDateLayouts = []string{
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, _2 Jan 2006 15:04:05 -0700",
+	"Mon, _2 Jan 2006 15:04:05 MST",
+	"Mon, _2 Jan 2006 15:04 -0700",
+	"Mon, _2 Jan 2006 15:04 MST",
+	"_2 Jan 2006 15:04:05 -0700",
+	"_2 Jan 2006 15:04:05 MST",
+	"Mon, _2 January 2006 15:04:05 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedDate tries all the date formats commonly found in the wild
+func parseFeedDate(str string) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return time.Time{}, errors.New("empty date")
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format: %s", str)
+}
+
+// parseItunesDuration converts "[[HH:]MM:]SS" (or plain seconds) to seconds, 0 if it cannot be parsed
+func parseItunesDuration(str string) int64 {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return 0
+	}
+	var ret int64
+	for _, part := range strings.Split(str, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		ret = ret*60 + int64(n)
+	}
+	return ret
+}
+
+func truncate(str string, maxLen int) string {
+	str = strings.TrimSpace(str)
+	if len(str) <= maxLen {
+		return str
+	}
+	str = str[:maxLen]
+	for !utf8.ValidString(str) {
+		str = str[:len(str)-1]
+	}
+	return str + "..."
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFeedDate(t *testing.T) {
+	cest := time.FixedZone("", 2*60*60)
+	tests := []struct {
+		str      string
+		expected time.Time
+	}{
+		{"2026-10-18T10:07:30Z", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{"2026-10-18T12:07:30+02:00", time.Date(2026, 10, 18, 12, 7, 30, 0, cest)},
+		{"2026-10-18T10:07:30.123456Z", time.Date(2026, 10, 18, 10, 7, 30, 123456000, time.UTC)},
+		{"Sun, 18 Oct 2026 12:07:30 +0200", time.Date(2026, 10, 18, 12, 7, 30, 0, cest)},
+		{"Sun, 18 Oct 2026 10:07:30 GMT", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{"Sun, 4 Oct 2026 10:07:30 +0000", time.Date(2026, 10, 4, 10, 7, 30, 0, time.UTC)},
+		{"Sun, 18 Oct 2026 12:07 +0200", time.Date(2026, 10, 18, 12, 7, 0, 0, cest)},
+		{"Sun, 18 Oct 2026 10:07 UTC", time.Date(2026, 10, 18, 10, 7, 0, 0, time.UTC)},
+		{"18 Oct 2026 12:07:30 +0200", time.Date(2026, 10, 18, 12, 7, 30, 0, cest)},
+		{"18 Oct 2026 10:07:30 GMT", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{"Sun, 18 October 2026 10:07:30 GMT", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{"2026-10-18T10:07:30", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{" 2026-10-18 10:07:30 ", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)},
+		{"2026-10-18", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		date, err := parseFeedDate(test.str)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.str, err)
+			continue
+		}
+		if !date.Equal(test.expected) {
+			t.Errorf("%q: date is %v, expected %v", test.str, date, test.expected)
+		}
+	}
+}
+
+func TestParseFeedDateErrors(t *testing.T) {
+	for _, str := range []string{"", "  ", "yesterday", "18.10.2026", "2026-13-18"} {
+		if _, err := parseFeedDate(str); err == nil {
+			t.Errorf("%q: expected an error", str)
+		}
+	}
+}
+
+func TestParseXMLFeedCharset(t *testing.T) {
+	body := []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+		"<rss version=\"2.0\"><channel><title>Caf\xe9</title><item><title>Cr\xe8me br\xfbl\xe9e</title><link>https://example.com/a</link></item></channel></rss>")
+	f, err := parseXMLFeed(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Café" || len(f.Items) != 1 || f.Items[0].Title != "Crème brûlée" {
+		t.Errorf("latin-1 feed is decoded as %q with items %+v", f.Title, f.Items)
+	}
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"regexp"
+	"strings"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Github provides the releases (and optionally the tags & commits of the default branch) of repositories via their atom feeds
+type Github struct{}
+
+// Kind ...
+func (Github) Kind() string {
+	return models.KindGithub
+}
+
+// Icon ...
+func (Github) Icon() string {
+	return "M12 .297c-6.63 0-12 5.373-12 12 0 5.303 3.438 9.8 8.205 11.385.6.113.82-.258.82-.577 0-.285-.01-1.04-.015-2.04-3.338.724-4.042-1.61-4.042-1.61C4.422 18.07 3.633 17.7 3.633 17.7c-1.087-.744.084-.729.084-.729 1.205.084 1.838 1.236 1.838 1.236 1.07 1.835 2.809 1.305 3.495.998.108-.776.417-1.305.76-1.605-2.665-.3-5.466-1.332-5.466-5.93 0-1.31.465-2.38 1.235-3.22-.135-.303-.54-1.523.105-3.176 0 0 1.005-.322 3.3 1.23.96-.267 1.98-.399 3-.405 1.02.006 2.04.138 3 .405 2.28-1.552 3.285-1.23 3.285-1.23.645 1.653.24 2.873.12 3.176.765.84 1.23 1.91 1.23 3.22 0 4.61-2.805 5.625-5.475 5.92.42.36.81 1.096.81 2.22 0 1.606-.015 2.896-.015 3.286 0 .315.21.69.825.57C20.565 22.092 24 17.592 24 12.297c0-6.627-5.373-12-12-12"
+}
+
+// SettingsHint ...
+func (Github) SettingsHint() string {
+	return "examples: \n  https://github.com/golang/go (releases) \n  https://github.com/golang/go?tags?commits (releases, tags & commits of the default branch)"
+}
+
+// ChannelURL ...
+func (Github) ChannelURL(externalID string) string {
+	repo, _, _ := parseGithubExternalID(externalID)
+	return "https://github.com/" + repo
+}
+
+// ContentURL ...
+func (Github) ContentURL(externalID string) string {
+	return "https://github.com/" + externalID // i.e. "golang/go/releases/tag/go1.16"
+}
+
+// ValidateChannel ...
+func (Github) ValidateChannel(data string) bool {
+	return extractGithubExternalID(data) != ""
+}
+
+// MetaData ...
+func (Github) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractGithubExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	repo, _, _ := parseGithubExternalID(externalID)
+	owner := strings.Split(repo, "/")[0]
+
+	return repo, models.KindGithub, "https://github.com/" + owner + ".png", externalID
+}
+
+// Fetch ...
+func (Github) Fetch(job *Job) error {
+	repo, tags, commits := parseGithubExternalID(job.Channel.ExternalID)
+	err := fetchGithubFeed(job, repo+"/releases.atom", "")
+	if err != nil {
+		return err
+	}
+	if tags { // tags of releases are already stored with the release & get skipped
+		if err := fetchGithubFeed(job, repo+"/tags.atom", "tag: "); err != nil {
+			logging.Println(logging.Error, "Channel:", job.Channel.Name, "--Error:", err)
+		}
+	}
+	if commits {
+		if err := fetchGithubFeed(job, repo+"/commits.atom", "commit: "); err != nil {
+			logging.Println(logging.Error, "Channel:", job.Channel.Name, "--Error:", err)
+		}
+	}
+	return nil
+}
+
+func fetchGithubFeed(job *Job, feedPath, titlePrefix string) error {
+	feedURL := "https://github.com/" + feedPath
+	body, err := httpGetBody(feedURL)
+	if err != nil {
+		return err
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range f.Items {
+		link := resolveURL(feedURL, item.Link)
+		if !strings.HasPrefix(link, "https://github.com/") {
+			continue
+		}
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = item.Date.In(job.Loc)
+		content.ExternalID = strings.TrimPrefix(link, "https://github.com/")
+		content.Title = titlePrefix + item.Title
+
+		if content.Date.Before(job.DateCutoff) || len(content.ExternalID) > 255 {
+			continue
+		}
+
+		err = job.Services.ContentService.CreateContent(&content)
+		if err != nil {
+			continue
+		}
+
+		// the media:thumbnail of the entries is the avatar of the author, the release notes may contain screenshots though
+		for _, img := range htmlImages(link, item.Description) {
+			if !strings.Contains(img, "avatars.githubusercontent.com") {
+				addMedia(job, content.ID, img)
+			}
+		}
+	}
+	return nil
+}
+
+// i.e. "https://github.com/golang/go", "github.com/golang/go/releases"
+var githubRegEx = regexp.MustCompile(`^(?:https?:\/\/)?(?:www\.)?github\.com\/([A-Za-z0-9_.-]+)\/([A-Za-z0-9_.-]+)`)
+
+// extractGithubExternalID returns "owner/repo", followed by the options "?tags" & "?commits"
+func extractGithubExternalID(data string) string {
+	data, tags, commits := parseGithubExternalID(data)
+	res := githubRegEx.FindStringSubmatch(data)
+	if res == nil {
+		return ""
+	}
+	repo := res[1] + "/" + strings.TrimSuffix(res[2], ".git")
+	if err := httpCanGet("HEAD", "https://github.com/"+repo); err != nil {
+		logging.Println(logging.Info, err)
+		return ""
+	}
+	if tags {
+		repo += "?tags"
+	}
+	if commits {
+		repo += "?commits"
+	}
+	return repo
+}
+
+// parseGithubExternalID strips the options from the external id (or user input), they can be given in any order
+func parseGithubExternalID(externalID string) (repo string, tags, commits bool) {
+	repo = externalID
+	for {
+		var found bool
+		if repo, found = trimChannelOption(repo, "tags"); found {
+			tags = true
+			continue
+		}
+		if repo, found = trimChannelOption(repo, "commits"); found {
+			commits = true
+			continue
+		}
+		return
+	}
+}
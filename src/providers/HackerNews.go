@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+const hackerNewsURL = "https://news.ycombinator.com/"
+
+// HackerNews provides the front page, show hn, ask hn or the submissions of a user via the algolia api
+type HackerNews struct{}
+
+// Kind ...
+func (HackerNews) Kind() string {
+	return models.KindHackerNews
+}
+
+// Icon ...
+func (HackerNews) Icon() string {
+	return "M0 24V0h24v24H0zM6.951 5.896l4.112 7.708v5.064h1.583v-4.972l4.148-7.799h-1.749l-2.457 4.875c-.372.745-.688 1.434-.688 1.434s-.297-.708-.651-1.434L8.831 5.896h-1.88z"
+}
+
+// SettingsHint ...
+func (HackerNews) SettingsHint() string {
+	return "examples: \n  https://news.ycombinator.com (front page) \n  https://news.ycombinator.com/show \n  https://news.ycombinator.com/ask \n  https://news.ycombinator.com/user?id=pg (submissions of a user)"
+}
+
+// ChannelURL ...
+func (HackerNews) ChannelURL(externalID string) string {
+	if strings.HasPrefix(externalID, "user/") {
+		return hackerNewsURL + "submitted?id=" + url.QueryEscape(strings.TrimPrefix(externalID, "user/"))
+	}
+	if externalID == "front" {
+		return hackerNewsURL + "news"
+	}
+	return hackerNewsURL + externalID
+}
+
+// ContentURL ...
+func (HackerNews) ContentURL(externalID string) string {
+	return externalID // url of the story or its discussion
+}
+
+// ValidateChannel ...
+func (HackerNews) ValidateChannel(data string) bool {
+	return extractHackerNewsExternalID(data) != ""
+}
+
+// MetaData ...
+func (HackerNews) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractHackerNewsExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	author := "hacker news: " + externalID
+	if strings.HasPrefix(externalID, "user/") {
+		author = "hacker news: " + strings.TrimPrefix(externalID, "user/")
+	}
+
+	return author, models.KindHackerNews, "", externalID
+}
+
+// Fetch ...
+func (HackerNews) Fetch(job *Job) error {
+	endpoint := "search_by_date?hitsPerPage=50&tags="
+	switch externalID := job.Channel.ExternalID; {
+	case externalID == "front":
+		endpoint = "search?hitsPerPage=50&tags=front_page"
+	case externalID == "show":
+		endpoint += "show_hn"
+	case externalID == "ask":
+		endpoint += "ask_hn"
+	case strings.HasPrefix(externalID, "user/"):
+		endpoint += url.QueryEscape("story,author_" + strings.TrimPrefix(externalID, "user/"))
+	}
+	body, err := httpGetBody("https://hn.algolia.com/api/v1/" + endpoint)
+	if err != nil {
+		return err
+	}
+
+	type hit struct {
+		ObjectID    string
+		Title       string
+		URL         string
+		Points      int64
+		NumComments int64 `json:"num_comments"`
+		CreatedAtI  int64 `json:"created_at_i"`
+	}
+	type result struct {
+		Hits []hit
+	}
+	var r result
+	err = json.Unmarshal(body, &r)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range r.Hits {
+		discussionURL := hackerNewsURL + "item?id=" + item.ObjectID
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = time.Unix(item.CreatedAtI, 0).UTC().In(job.Loc)
+		content.ExternalID = item.URL
+		if content.ExternalID == "" || len(content.ExternalID) > 255 { // ask hn & co. have no link
+			content.ExternalID = discussionURL
+		}
+		content.Title = item.Title
+		content.Score = sql.NullInt64{Int64: item.Points, Valid: true}
+		content.Comments = sql.NullInt64{Int64: item.NumComments, Valid: true}
+		content.DiscussionURL = sql.NullString{String: discussionURL, Valid: true}
+
+		storeStory(job, &content, item.URL)
+	}
+	return nil
+}
+
+// i.e. "https://news.ycombinator.com", "https://news.ycombinator.com/show", "https://news.ycombinator.com/submitted?id=pg"
+var hackerNewsRegEx = regexp.MustCompile(`^(?:https?:\/\/)?news\.ycombinator\.com\/?([a-z]*)(?:\?id=([A-Za-z0-9_-]+))?`)
+
+func extractHackerNewsExternalID(data string) string {
+	res := hackerNewsRegEx.FindStringSubmatch(strings.TrimSpace(data))
+	if res == nil {
+		return ""
+	}
+	switch res[1] {
+	case "", "news", "front":
+		return "front"
+	case "show", "ask":
+		return res[1]
+	case "user", "submitted":
+		if res[2] == "" {
+			return ""
+		}
+		if err := httpCanGet("GET", "https://hn.algolia.com/api/v1/users/"+res[2]); err != nil {
+			logging.Println(logging.Info, err)
+			return ""
+		}
+		return "user/" + res[2]
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package providers
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var htmlLinkTagRegEx = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+var htmlMetaTagRegEx = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+var htmlImgTagRegEx = regexp.MustCompile(`(?is)<img\s[^>]*>`)
+var htmlAttrRegEx = regexp.MustCompile(`(?is)([a-z:_-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+
+var feedMimeTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/feed+json",
+	"application/json",
+	"application/xml",
+	"text/xml",
+}
+
+// htmlAttributes parses the attributes of a single html tag, names are lower cased
+func htmlAttributes(tag string) map[string]string {
+	ret := map[string]string{}
+	for _, m := range htmlAttrRegEx.FindAllStringSubmatch(tag, -1) {
+		ret[strings.ToLower(m[1])] = html.UnescapeString(strings.Trim(m[2], `"'`))
+	}
+	return ret
+}
+
+// resolveURL resolves a (potentially relative) reference against the base URL
+func resolveURL(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// discoverFeedURLs returns all feeds advertised via <link rel="alternate"> in a html page
+func discoverFeedURLs(pageURL string, body []byte) []string {
+	ret := []string{}
+	for _, tag := range htmlLinkTagRegEx.FindAllString(string(body), -1) {
+		attrs := htmlAttributes(tag)
+		if !strings.Contains(strings.ToLower(attrs["rel"]), "alternate") || attrs["href"] == "" {
+			continue
+		}
+		mimeType := strings.ToLower(attrs["type"])
+		for _, t := range feedMimeTypes {
+			if mimeType == t {
+				ret = append(ret, resolveURL(pageURL, attrs["href"]))
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// openGraphImage returns the og:image (or twitter:image) of a html page
+func openGraphImage(pageURL string, body []byte) string {
+	fallback := ""
+	for _, tag := range htmlMetaTagRegEx.FindAllString(string(body), -1) {
+		attrs := htmlAttributes(tag)
+		name := attrs["property"]
+		if name == "" {
+			name = attrs["name"]
+		}
+		switch strings.ToLower(name) {
+		case "og:image", "og:image:url", "og:image:secure_url":
+			if attrs["content"] != "" {
+				return resolveURL(pageURL, attrs["content"])
+			}
+		case "twitter:image", "twitter:image:src":
+			if fallback == "" && attrs["content"] != "" {
+				fallback = resolveURL(pageURL, attrs["content"])
+			}
+		}
+	}
+	return fallback
+}
+
+// queryOpenGraphImage loads a html page and returns its og:image
+func queryOpenGraphImage(pageURL string) string {
+	if !strings.HasPrefix(pageURL, "http") {
+		return ""
+	}
+	body, err := httpGetBody(pageURL)
+	if err != nil {
+		return ""
+	}
+	return openGraphImage(pageURL, body)
+}
+
+// htmlImages returns the sources of all <img> tags of a html snippet
+func htmlImages(baseURL, snippet string) []string {
+	ret := []string{}
+	for _, tag := range htmlImgTagRegEx.FindAllString(snippet, -1) {
+		src := htmlAttributes(tag)["src"]
+		if src != "" && !strings.HasPrefix(src, "data:") {
+			ret = append(ret, resolveURL(baseURL, src))
+		}
+	}
+	return ret
+}
+
+var htmlBreakRegEx = regexp.MustCompile(`(?is)<br\s*/?>|</p>`)
+var htmlTagRegEx = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// htmlToText strips all tags of a html snippet, line breaks & paragraphs become new lines
+func htmlToText(snippet string) string {
+	text := htmlBreakRegEx.ReplaceAllString(snippet, "\n")
+	text = htmlTagRegEx.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
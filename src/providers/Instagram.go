@@ -0,0 +1,296 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// instagramAppID is the id of instagram's web app, the api rejects requests without it
+const instagramAppID = "936619743392459"
+
+// instagramProfileTTL is how long a looked up profile is kept for the first fetch of a new channel
+const instagramProfileTTL = 10 * time.Minute
+
+// Instagram provides instagram profiles via the (heavily rate limited) web api of instagram.
+// every request counts towards a daily budget, which is spread across the background runs of the day
+type Instagram struct {
+	dailyRequests int
+	sessionID     string
+	client        *http.Client
+
+	mu       sync.Mutex
+	day      string // utc date of the used requests
+	used     int
+	profiles map[string]instagramProfile // looked up profiles by username, i.e. while adding a channel
+}
+
+type instagramProfile struct {
+	user *instagramUser
+	at   time.Time
+}
+
+// NewInstagram creates the instagram provider with a daily request budget & an optional session cookie (sessionid) of a logged in user.
+// nil is returned if there is no budget
+func NewInstagram(dailyRequests int, sessionID string) *Instagram {
+	if dailyRequests <= 0 {
+		return nil
+	}
+	return &Instagram{dailyRequests: dailyRequests, sessionID: sessionID, client: newHTTPClient(), profiles: map[string]instagramProfile{}}
+}
+
+// Kind ...
+func (*Instagram) Kind() string {
+	return models.KindInstagram
+}
+
+// Icon ...
+func (*Instagram) Icon() string {
+	return "M12 0C8.74 0 8.333.015 7.053.072 5.775.132 4.905.333 4.14.63c-.789.306-1.459.717-2.126 1.384S.935 3.35.63 4.14C.333 4.905.131 5.775.072 7.053.012 8.333 0 8.74 0 12s.015 3.667.072 4.947c.06 1.277.261 2.148.558 2.913.306.788.717 1.459 1.384 2.126.667.666 1.336 1.079 2.126 1.384.766.296 1.636.499 2.913.558C8.333 23.988 8.74 24 12 24s3.667-.015 4.947-.072c1.277-.06 2.148-.262 2.913-.558.788-.306 1.459-.718 2.126-1.384.666-.667 1.079-1.335 1.384-2.126.296-.765.499-1.636.558-2.913.06-1.28.072-1.687.072-4.947s-.015-3.667-.072-4.947c-.06-1.277-.262-2.149-.558-2.913-.306-.789-.718-1.459-1.384-2.126C21.319 1.347 20.651.935 19.86.63c-.765-.297-1.636-.499-2.913-.558C15.667.012 15.26 0 12 0zm0 2.16c3.203 0 3.585.016 4.85.071 1.17.055 1.805.249 2.227.415.562.217.96.477 1.382.896.419.42.679.819.896 1.381.164.422.36 1.057.413 2.227.057 1.266.07 1.646.07 4.85s-.015 3.585-.074 4.85c-.061 1.17-.256 1.805-.421 2.227-.224.562-.479.96-.899 1.382-.419.419-.824.679-1.38.896-.42.164-1.065.36-2.235.413-1.274.057-1.649.07-4.859.07-3.211 0-3.586-.015-4.859-.074-1.171-.061-1.816-.256-2.236-.421-.569-.224-.96-.479-1.379-.899-.421-.419-.69-.824-.9-1.38-.165-.42-.359-1.065-.42-2.235-.045-1.26-.061-1.649-.061-4.844 0-3.196.016-3.586.061-4.861.061-1.17.255-1.814.42-2.234.21-.57.479-.96.9-1.381.419-.419.81-.689 1.379-.898.42-.166 1.051-.361 2.221-.421 1.275-.045 1.65-.06 4.859-.06l.045.03zm0 3.678c-3.405 0-6.162 2.76-6.162 6.162 0 3.405 2.76 6.162 6.162 6.162 3.405 0 6.162-2.76 6.162-6.162 0-3.405-2.76-6.162-6.162-6.162zM12 16c-2.21 0-4-1.79-4-4s1.79-4 4-4 4 1.79 4 4-1.79 4-4 4zm7.846-10.405c0 .795-.646 1.44-1.44 1.44-.795 0-1.44-.646-1.44-1.44 0-.794.646-1.439 1.44-1.439.793-.001 1.44.645 1.44 1.439z"
+}
+
+// SettingsHint ...
+func (*Instagram) SettingsHint() string {
+	return "example \n  https://www.instagram.com/fundotcom_/?hl=en"
+}
+
+// ChannelURL ...
+func (*Instagram) ChannelURL(externalID string) string {
+	return "https://instagram.com/" + externalID
+}
+
+// ContentURL ...
+func (*Instagram) ContentURL(externalID string) string {
+	return "https://instagram.com/p/" + externalID // shortcode, i.e. "<some code>"
+}
+
+// ValidateChannel ...
+func (i *Instagram) ValidateChannel(data string) bool {
+	user, err := i.lookupUser(extractInstagramUsername(data))
+	if err != nil {
+		logging.Println(logging.Info, err)
+	}
+	return user != nil
+}
+
+// MetaData ...
+func (i *Instagram) MetaData(channelID string) (string, string, string, string) {
+	username := extractInstagramUsername(channelID)
+	user, err := i.lookupUser(username)
+	if user == nil {
+		logging.Println(logging.Info, err)
+		return "", "", "", ""
+	}
+
+	return username, models.KindInstagram, user.ProfilePicURLHD, username
+}
+
+// Schedule picks as many due channels as the budget accrued so far today allows, the least recently fetched channels first
+func (i *Instagram) Schedule(channels, all []models.Channel) ([]models.Channel, []models.Channel) {
+	now := time.Now().UTC()
+	midnight := now.Truncate(24 * time.Hour)
+
+	i.mu.Lock()
+	i.resetDay(now)
+	fetchedToday := 0 // survives restarts, unlike the counter
+	for _, c := range all {
+		if c.LastFetch.Valid && !c.LastFetch.Time.Before(midnight) {
+			fetchedToday++
+		}
+	}
+	if fetchedToday > i.used {
+		i.used = fetchedToday
+	}
+	accrued := int(math.Ceil(float64(i.dailyRequests) * now.Sub(midnight).Hours() / 24))
+	allowance := accrued - i.used
+	i.mu.Unlock()
+
+	sort.SliceStable(channels, func(a, b int) bool {
+		if channels[a].LastFetch.Valid != channels[b].LastFetch.Valid {
+			return !channels[a].LastFetch.Valid // never fetched
+		}
+		return channels[a].LastFetch.Time.Before(channels[b].LastFetch.Time)
+	})
+	if allowance < 0 {
+		allowance = 0
+	}
+	if allowance > len(channels) {
+		allowance = len(channels)
+	}
+	return channels[:allowance], channels[allowance:]
+}
+
+// Fetch ...
+func (i *Instagram) Fetch(job *Job) error {
+	var err error
+	user := i.cachedUser(job.Channel.ExternalID, true) // the first fetch of a new channel reuses the profile of its lookup
+	if user == nil {
+		user, err = i.queryUser(job.Channel.ExternalID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range user.EdgeOwnerToTimelineMedia.Edges {
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = time.Unix(int64(edge.Node.TakenAtTimestamp), 0).UTC().In(job.Loc)
+		content.ExternalID = edge.Node.Shortcode // https://instagram.com/p/ + shortcode
+		if content.ExternalID == "" {
+			continue
+		}
+		content.Title = ""
+		for _, capEdge := range edge.Node.EdgeMediaToCaption.Edges {
+			content.Title += capEdge.Node.Text
+		}
+
+		if content.Date.Before(job.DateCutoff) {
+			continue // pinned posts come first
+		}
+
+		err = createContent(job, &content)
+		if err != nil {
+			continue
+		}
+
+		switch edge.Node.Typename {
+		case "GraphVideo", "GraphImage":
+			addMedia(job, content.ID, edge.Node.DisplayURL)
+		case "GraphSidecar":
+			for _, sidecar := range edge.Node.EdgeSidecarToChildren.Edges {
+				addMedia(job, content.ID, sidecar.Node.DisplayURL)
+			}
+		}
+	}
+	return nil
+}
+
+type instagramUser struct {
+	ProfilePicURLHD          string `json:"profile_pic_url_hd"`
+	EdgeOwnerToTimelineMedia struct {
+		Edges []struct {
+			Node struct {
+				Shortcode          string
+				Typename           string `json:"__typename"`  // GraphSidecar (multiple media), GraphImage (media type #1), GraphVideo (media type #2)
+				DisplayURL         string `json:"display_url"` // for graph image
+				EdgeMediaToCaption struct {
+					Edges []struct {
+						Node struct {
+							Text string
+						}
+					}
+				} `json:"edge_media_to_caption"`
+				EdgeSidecarToChildren struct {
+					Edges []struct {
+						Node struct {
+							DisplayURL string `json:"display_url"`
+						}
+					}
+				} `json:"edge_sidecar_to_children"`
+				TakenAtTimestamp float64 `json:"taken_at_timestamp"`
+			}
+		}
+	} `json:"edge_owner_to_timeline_media"`
+}
+
+// lookupUser returns a recently looked up profile or queries it, the profile is kept for the first fetch
+func (i *Instagram) lookupUser(username string) (*instagramUser, error) {
+	if user := i.cachedUser(username, false); user != nil {
+		return user, nil
+	}
+	user, err := i.queryUser(username)
+	if err != nil {
+		return nil, err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for name, profile := range i.profiles {
+		if time.Since(profile.at) > instagramProfileTTL {
+			delete(i.profiles, name)
+		}
+	}
+	i.profiles[username] = instagramProfile{user: user, at: time.Now()}
+	return user, nil
+}
+
+// cachedUser returns a profile, which was looked up within instagramProfileTTL. taken profiles are removed from the cache
+func (i *Instagram) cachedUser(username string, take bool) *instagramUser {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	profile, ok := i.profiles[username]
+	if !ok || time.Since(profile.at) > instagramProfileTTL {
+		return nil
+	}
+	if take {
+		delete(i.profiles, username)
+	}
+	return profile.user
+}
+
+// queryUser loads the profile & the latest posts of a user, which costs one request of the budget
+func (i *Instagram) queryUser(username string) (*instagramUser, error) {
+	if username == "" {
+		return nil, errors.New("invalid instagram username")
+	}
+	if !i.take() {
+		return nil, ErrBudgetExhausted
+	}
+	header := http.Header{"X-Ig-App-Id": []string{instagramAppID}}
+	if i.sessionID != "" {
+		header.Set("Cookie", "sessionid="+i.sessionID)
+	}
+	body, err := httpGetBodyWith(i.client, "https://i.instagram.com/api/v1/users/web_profile_info/?username="+url.QueryEscape(username), header)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data struct {
+			User *instagramUser
+		}
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.User == nil {
+		return nil, errors.New("unknown instagram user " + username)
+	}
+	return resp.Data.User, nil
+}
+
+// take uses up one request of today's budget, if there is any left
+func (i *Instagram) take() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.resetDay(time.Now().UTC())
+	if i.used >= i.dailyRequests {
+		return false
+	}
+	i.used++
+	return true
+}
+
+func (i *Instagram) resetDay(now time.Time) {
+	if day := now.Format("2006-01-02"); day != i.day {
+		i.day = day
+		i.used = 0
+	}
+}
+
+// i.e. "https://www.instagram.com/fundotcom_/?hl=en"
+var instaRegEx = regexp.MustCompile(`instagram\.com\/([A-Za-z0-9_.]+)`)
+
+func extractInstagramUsername(data string) string {
+	res := instaRegEx.FindStringSubmatch(data)
+	if res == nil {
+		return ""
+	}
+	return res[1]
+}
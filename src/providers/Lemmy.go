@@ -0,0 +1,221 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Lemmy provides communities of any lemmy instance via its json api
+type Lemmy struct{}
+
+// Kind ...
+func (Lemmy) Kind() string {
+	return models.KindLemmy
+}
+
+// Icon ...
+func (Lemmy) Icon() string {
+	return "M12 2C6.8 2 3 5.4 3 10.2c0 1.6.4 3.1 1.2 4.4L2 20l4.3-1.7c1.7 1 3.6 1.5 5.7 1.5 5.2 0 9-3.4 9-8.2v-.4C21 6.2 17.2 2 12 2zm-3.5 7a1.5 1.5 0 1 1 0 3 1.5 1.5 0 0 1 0-3zm7 0a1.5 1.5 0 1 1 0 3 1.5 1.5 0 0 1 0-3zM8 14h8c-.8 1.5-2.3 2.4-4 2.4S8.8 15.5 8 14zM5.5 1 8 4.5 6.8 5.3 4.3 1.8zm13 0 1.2.8-2.5 3.5L16 4.5z"
+}
+
+// SettingsHint ...
+func (Lemmy) SettingsHint() string {
+	return "examples: \n  https://lemmy.ml/c/golang \n  !golang@lemmy.ml"
+}
+
+// ChannelURL ...
+func (Lemmy) ChannelURL(externalID string) string {
+	return "https://" + externalID // i.e. "lemmy.ml/c/golang"
+}
+
+// ContentURL ...
+func (Lemmy) ContentURL(externalID string) string {
+	return "https://" + externalID // i.e. "lemmy.ml/post/1234"
+}
+
+// ValidateChannel ...
+func (Lemmy) ValidateChannel(data string) bool {
+	return queryLemmyCommunity(data) != nil
+}
+
+// MetaData ...
+func (Lemmy) MetaData(channelID string) (string, string, string, string) {
+	community := queryLemmyCommunity(channelID)
+	if community == nil {
+		return "", "", "", ""
+	}
+	author := community.Title
+	if author == "" {
+		author = community.Name
+	}
+
+	return author, models.KindLemmy, community.Icon, community.externalID
+}
+
+type lemmyPostView struct {
+	Post struct {
+		ID                int64
+		Name              string
+		URL               string
+		ThumbnailURL      string `json:"thumbnail_url"`
+		Body              string
+		Published         string
+		FeaturedCommunity bool `json:"featured_community"` // pinned on top of the community
+	}
+}
+
+// Fetch pages backward until the high-water mark or the cutoff is reached
+func (Lemmy) Fetch(job *Job) error {
+	host, name := splitLemmyExternalID(job.Channel.ExternalID)
+	query := url.Values{}
+	query.Set("community_name", name)
+	query.Set("sort", "New")
+	query.Set("limit", "50")
+	for page := 1; page <= listingMaxPages; page++ {
+		query.Set("page", strconv.Itoa(page))
+		body, err := getChannel(job, "https://"+host+"/api/v3/post/list?"+query.Encode(), page-1)
+		if err != nil {
+			return err
+		}
+
+		var p struct {
+			Posts []lemmyPostView
+		}
+		err = json.Unmarshal(body, &p)
+		if err != nil {
+			return err
+		}
+		if storeLemmyPosts(job, host, p.Posts) {
+			break
+		}
+	}
+	return nil
+}
+
+// storeLemmyPosts returns true, if the high-water mark or the cutoff was reached
+func storeLemmyPosts(job *Job, host string, posts []lemmyPostView) bool {
+	reachedEnd := len(posts) == 0
+	for _, item := range posts {
+		date, err := parseFeedDate(item.Post.Published) // lemmy < 0.19 omits the time zone, which is utc
+		if err != nil {
+			logging.Println(logging.Debug, err)
+			continue
+		}
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = date.In(job.Loc)
+		content.ExternalID = host + "/post/" + strconv.FormatInt(item.Post.ID, 10)
+		content.Title = item.Post.Name
+
+		if item.Post.FeaturedCommunity { // pinned posts aren't sorted by date
+			if content.Date.Before(job.DateCutoff) || job.Services.ContentService.CreateContent(&content) != nil {
+				continue
+			}
+		} else {
+			if content.Date.Before(job.DateCutoff) {
+				reachedEnd = true
+				continue
+			}
+			err = createContent(job, &content)
+			if err == errKnownContent {
+				reachedEnd = true
+			}
+			if err != nil {
+				continue
+			}
+		}
+
+		if isLemmyImage(item.Post.URL) {
+			addMedia(job, content.ID, item.Post.URL)
+		} else if strings.HasPrefix(item.Post.ThumbnailURL, "http") {
+			addMedia(job, content.ID, item.Post.ThumbnailURL)
+		}
+		for _, img := range markdownImages(item.Post.Body) { // multi image posts embed their images in the body
+			var media models.Media
+			media.ContentID = content.ID
+			media.URL = img[0]
+			if img[1] != "" {
+				media.Alt.String = img[1]
+				media.Alt.Valid = true
+			}
+			storeMedia(job, &media)
+		}
+	}
+	return reachedEnd
+}
+
+type lemmyCommunity struct {
+	Name  string
+	Title string
+	Icon  string
+
+	externalID string
+}
+
+// i.e. "https://lemmy.ml/c/golang", "https://lemmy.world/c/golang@lemmy.ml", "!golang@lemmy.ml"
+var lemmyCommunityURLRegEx = regexp.MustCompile(`^(?:https?:\/\/)?([A-Za-z0-9.-]+\.[A-Za-z]+(?::[0-9]+)?)\/c\/([A-Za-z0-9_]+(?:@[A-Za-z0-9.-]+)?)`)
+var lemmyCommunityHandleRegEx = regexp.MustCompile(`^!([A-Za-z0-9_]+)@([A-Za-z0-9.-]+\.[A-Za-z]+)$`)
+
+// splitLemmyExternalID splits "host/c/name" into the host & the community name
+func splitLemmyExternalID(externalID string) (string, string) {
+	parts := strings.SplitN(externalID, "/c/", 2)
+	if len(parts) != 2 {
+		return externalID, ""
+	}
+	return parts[0], parts[1]
+}
+
+func queryLemmyCommunity(data string) *lemmyCommunity {
+	data = strings.TrimSpace(data)
+	host, name := "", ""
+	if res := lemmyCommunityURLRegEx.FindStringSubmatch(data); res != nil {
+		host, name = strings.ToLower(res[1]), res[2]
+	} else if res := lemmyCommunityHandleRegEx.FindStringSubmatch(data); res != nil {
+		host, name = strings.ToLower(res[2]), res[1]
+	} else {
+		return nil
+	}
+
+	body, err := httpGetBody("https://" + host + "/api/v3/community?name=" + url.QueryEscape(name))
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	var resp struct {
+		CommunityView struct {
+			Community lemmyCommunity
+		} `json:"community_view"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.CommunityView.Community.Name == "" {
+		logging.Println(logging.Info, "lemmy community lookup failed for", name, "@", host, err)
+		return nil
+	}
+	community := resp.CommunityView.Community
+	community.externalID = host + "/c/" + name
+	return &community
+}
+
+// isLemmyImage checks the link of a post, uploads are served by pict-rs without an extension
+func isLemmyImage(link string) bool {
+	if u, err := url.Parse(link); err == nil && u.Host != "" {
+		return hasImageExtension(strings.ToLower(u.Path)) || strings.HasPrefix(u.Path, "/pictrs/image/")
+	}
+	return false
+}
+
+var markdownImageRegEx = regexp.MustCompile(`!\[([^\]]*)\]\((https?:\/\/[^)\s]+)`)
+
+// markdownImages returns the url & alt text of all images of a markdown text
+func markdownImages(markdown string) [][2]string {
+	ret := [][2]string{}
+	for _, m := range markdownImageRegEx.FindAllStringSubmatch(markdown, -1) {
+		ret = append(ret, [2]string{m[2], strings.TrimSpace(m[1])})
+	}
+	return ret
+}
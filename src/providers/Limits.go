@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// defaultRetryAfter is the pause of a host, which responded with 429 but without a Retry-After header
+const defaultRetryAfter = 1 * time.Minute
+
+// maxRetryWait is the longest Retry-After, which is waited for to retry a request, longer pauses fail the request
+const maxRetryWait = 30 * time.Second
+
+// HostLimit limits the requests to a single upstream host
+type HostLimit struct {
+	Concurrency int     // parallel requests
+	Rate        float64 // requests per second (token bucket), 0 is unlimited
+	Burst       int     // requests, which can be issued at once after a quiet period
+}
+
+var hostLimits = struct {
+	sync.Mutex
+	defaults  HostLimit
+	overrides map[string]HostLimit
+	limiters  map[string]*hostLimiter
+}{
+	defaults:  HostLimit{Concurrency: 4, Rate: 2, Burst: 4},
+	overrides: map[string]HostLimit{},
+	limiters:  map[string]*hostLimiter{},
+}
+
+// ConfigureHostLimits sets the default limit of all hosts & the limits of specific hosts.
+// the overrides are comma separated, i.e. "www.youtube.com=8:5,nitter.net=1:0.5" (host=concurrency:rate)
+func ConfigureHostLimits(defaults HostLimit, overrides string) error {
+	parsed := map[string]HostLimit{}
+	for _, override := range strings.Split(overrides, ",") {
+		if strings.TrimSpace(override) == "" {
+			continue
+		}
+		split := strings.SplitN(override, "=", 2)
+		values := strings.SplitN(split[len(split)-1], ":", 2)
+		if len(split) != 2 || len(values) != 2 {
+			return errors.New("invalid host limit " + override + ", expected host=concurrency:rate")
+		}
+		concurrency, err := strconv.Atoi(strings.TrimSpace(values[0]))
+		if err != nil {
+			return err
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(values[1]), 64)
+		if err != nil {
+			return err
+		}
+		parsed[strings.TrimSpace(split[0])] = HostLimit{Concurrency: concurrency, Rate: rate, Burst: concurrency}
+	}
+
+	hostLimits.Lock()
+	defer hostLimits.Unlock()
+	hostLimits.defaults = defaults
+	hostLimits.overrides = parsed
+	hostLimits.limiters = map[string]*hostLimiter{}
+	return nil
+}
+
+func hostLimiterFor(host string) *hostLimiter {
+	hostLimits.Lock()
+	defer hostLimits.Unlock()
+	l, ok := hostLimits.limiters[host]
+	if !ok {
+		limit, ok := hostLimits.overrides[host]
+		if !ok {
+			limit = hostLimits.defaults
+		}
+		if limit.Concurrency < 1 {
+			limit.Concurrency = 1
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		l = &hostLimiter{host: host, limit: limit, slots: make(chan struct{}, limit.Concurrency), tokens: float64(limit.Burst), last: time.Now()}
+		hostLimits.limiters[host] = l
+	}
+	return l
+}
+
+// hostLimiter bounds the parallel requests to a host & paces them by a token bucket
+type hostLimiter struct {
+	host  string
+	limit HostLimit
+	slots chan struct{}
+
+	mu           sync.Mutex
+	tokens       float64 // negative, if requests are waiting for tokens
+	last         time.Time
+	blockedUntil time.Time // the host asked to back off (Retry-After)
+}
+
+// acquire waits for a free slot & a token, it fails right away if the host is paused for longer than maxRetryWait
+func (l *hostLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	wait, err := l.reserve()
+	if err != nil {
+		l.release()
+		return err
+	}
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release()
+		return ctx.Err()
+	}
+}
+
+func (l *hostLimiter) release() {
+	<-l.slots
+}
+
+// reserve takes a token & returns how long to wait for it
+func (l *hostLimiter) reserve() (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	wait := l.blockedUntil.Sub(now)
+	if wait > maxRetryWait {
+		return 0, errors.New(l.host + " is rate limited until " + l.blockedUntil.Format(time.RFC3339))
+	}
+	if l.limit.Rate > 0 {
+		l.tokens = math.Min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limit.Rate)
+		l.last = now
+		l.tokens--
+		if l.tokens < 0 {
+			if tokenWait := time.Duration(-l.tokens / l.limit.Rate * float64(time.Second)); tokenWait > wait {
+				wait = tokenWait
+			}
+		}
+	}
+	return wait, nil
+}
+
+// block pauses all requests to the host
+func (l *hostLimiter) block(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+		logging.Println(logging.Info, l.host, "is rate limited, pausing its requests for", d)
+	}
+}
+
+// retryAfter parses the Retry-After header, which is either in seconds or a http date
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return defaultRetryAfter
+}
+
+// limitedTransport applies the host limits to all requests of the providers.
+// 429 responses (and 503 with Retry-After) pause the host, short pauses are waited for & the request is retried once
+type limitedTransport struct {
+	base http.RoundTripper
+}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := hostLimiterFor(req.URL.Hostname())
+	for attempt := 0; ; attempt++ {
+		if err := l.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		l.release()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests && (resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "") {
+			return resp, nil
+		}
+		wait := retryAfter(resp.Header)
+		l.block(wait)
+		if attempt > 0 || wait > maxRetryWait || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+}
+
+var limitedHTTPTransport http.RoundTripper = limitedTransport{base: http.DefaultTransport}
+
+// newHTTPClient creates a client, whose requests are subject to the host limits
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 1 * time.Minute, Transport: limitedHTTPTransport}
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigureHostLimits(t *testing.T) {
+	defaults := HostLimit{Concurrency: 4, Rate: 2, Burst: 4}
+	t.Cleanup(func() { ConfigureHostLimits(defaults, "") })
+
+	tests := []struct {
+		overrides string
+		expected  map[string]HostLimit
+	}{
+		{"", map[string]HostLimit{}},
+		{" , ", map[string]HostLimit{}},
+		{"nitter.net=1:0.5", map[string]HostLimit{"nitter.net": {Concurrency: 1, Rate: 0.5, Burst: 1}}},
+		{"www.youtube.com=8:5, nitter.net = 1 : 0 ", map[string]HostLimit{
+			"www.youtube.com": {Concurrency: 8, Rate: 5, Burst: 8},
+			"nitter.net":      {Concurrency: 1, Rate: 0, Burst: 1},
+		}},
+	}
+	for _, test := range tests {
+		if err := ConfigureHostLimits(defaults, test.overrides); err != nil {
+			t.Errorf("%q: unexpected error %v", test.overrides, err)
+			continue
+		}
+		if !reflect.DeepEqual(hostLimits.overrides, test.expected) {
+			t.Errorf("%q: overrides are %v, expected %v", test.overrides, hostLimits.overrides, test.expected)
+		}
+	}
+}
+
+func TestConfigureHostLimitsErrors(t *testing.T) {
+	defaults := HostLimit{Concurrency: 4, Rate: 2, Burst: 4}
+	t.Cleanup(func() { ConfigureHostLimits(defaults, "") })
+
+	for _, overrides := range []string{
+		"nitter.net",
+		"nitter.net=1",
+		"nitter.net=a:1",
+		"nitter.net=1:a",
+		"www.youtube.com=8:5,nitter.net",
+	} {
+		if err := ConfigureHostLimits(defaults, overrides); err == nil {
+			t.Errorf("%q: expected an error", overrides)
+		}
+	}
+}
+
+func TestHostLimiterFor(t *testing.T) {
+	t.Cleanup(func() { ConfigureHostLimits(HostLimit{Concurrency: 4, Rate: 2, Burst: 4}, "") })
+	if err := ConfigureHostLimits(HostLimit{Concurrency: 0, Rate: 2, Burst: 0}, "nitter.net=2:0.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		host     string
+		expected HostLimit
+	}{
+		{"nitter.net", HostLimit{Concurrency: 2, Rate: 0.5, Burst: 2}},
+		{"www.reddit.com", HostLimit{Concurrency: 1, Rate: 2, Burst: 1}}, // the defaults allow at least one request
+	}
+	for _, test := range tests {
+		l := hostLimiterFor(test.host)
+		if l.limit != test.expected || cap(l.slots) != test.expected.Concurrency {
+			t.Errorf("%s: limit is %+v with %d slots, expected %+v", test.host, l.limit, cap(l.slots), test.expected)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package providers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+const lobstersURL = "https://lobste.rs/"
+
+// Lobsters provides the front page, the newest stories or tags of lobste.rs via its json api
+type Lobsters struct{}
+
+// Kind ...
+func (Lobsters) Kind() string {
+	return models.KindLobsters
+}
+
+// Icon ...
+func (Lobsters) Icon() string {
+	return "M2 0h20a2 2 0 0 1 2 2v20a2 2 0 0 1-2 2H2a2 2 0 0 1-2-2V2a2 2 0 0 1 2-2zm5 5v14h10v-2.5H9.8V5z"
+}
+
+// SettingsHint ...
+func (Lobsters) SettingsHint() string {
+	return "examples: \n  https://lobste.rs (front page) \n  https://lobste.rs/newest \n  https://lobste.rs/t/go,rust (tags)"
+}
+
+// ChannelURL ...
+func (Lobsters) ChannelURL(externalID string) string {
+	if externalID == "hottest" {
+		return lobstersURL
+	}
+	return lobstersURL + externalID
+}
+
+// ContentURL ...
+func (Lobsters) ContentURL(externalID string) string {
+	return externalID // url of the story or its discussion
+}
+
+// ValidateChannel ...
+func (Lobsters) ValidateChannel(data string) bool {
+	return extractLobstersExternalID(data) != ""
+}
+
+// MetaData ...
+func (Lobsters) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractLobstersExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	author := "lobsters: " + strings.TrimPrefix(externalID, "t/")
+
+	return author, models.KindLobsters, "", externalID
+}
+
+// Fetch ...
+func (Lobsters) Fetch(job *Job) error {
+	body, err := httpGetBody(lobstersURL + job.Channel.ExternalID + ".json")
+	if err != nil {
+		return err
+	}
+
+	type story struct {
+		Title        string
+		URL          string
+		Score        int64
+		CommentCount int64     `json:"comment_count"`
+		CommentsURL  string    `json:"comments_url"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+	var stories []story
+	err = json.Unmarshal(body, &stories)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range stories {
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = item.CreatedAt.In(job.Loc)
+		content.ExternalID = item.URL
+		if content.ExternalID == "" || len(content.ExternalID) > 255 { // text posts have no link
+			content.ExternalID = item.CommentsURL
+		}
+		content.Title = item.Title
+		content.Score = sql.NullInt64{Int64: item.Score, Valid: true}
+		content.Comments = sql.NullInt64{Int64: item.CommentCount, Valid: true}
+		content.DiscussionURL = sql.NullString{String: item.CommentsURL, Valid: item.CommentsURL != ""}
+
+		storeStory(job, &content, item.URL)
+	}
+	return nil
+}
+
+// i.e. "https://lobste.rs", "https://lobste.rs/newest", "https://lobste.rs/t/go,rust"
+var lobstersRegEx = regexp.MustCompile(`^(?:https?:\/\/)?lobste\.rs\/?(hottest|newest|active|t\/[a-z0-9_,+-]+)?\/?$`)
+
+func extractLobstersExternalID(data string) string {
+	res := lobstersRegEx.FindStringSubmatch(strings.TrimSpace(data))
+	if res == nil {
+		return ""
+	}
+	externalID := res[1]
+	if externalID == "" {
+		externalID = "hottest"
+	}
+	if err := httpCanGet("GET", lobstersURL+externalID+".json"); err != nil { // unknown tags
+		logging.Println(logging.Info, err)
+		return ""
+	}
+	return externalID
+}
@@ -0,0 +1,267 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Mastodon provides accounts of mastodon compatible servers via their public api
+type Mastodon struct{}
+
+// Kind ...
+func (Mastodon) Kind() string {
+	return models.KindMastodon
+}
+
+// Icon ...
+func (Mastodon) Icon() string {
+	return "M23.268 5.313c-.35-2.578-2.617-4.61-5.304-5.004C17.51.242 15.792 0 11.813 0h-.03c-3.98 0-4.835.242-5.288.309C3.882.692 1.496 2.518.917 5.127.64 6.412.61 7.837.661 9.143c.074 1.874.088 3.745.26 5.611.118 1.24.325 2.47.62 3.68.55 2.237 2.777 4.098 4.96 4.857 2.336.792 4.849.923 7.256.38.265-.061.527-.132.786-.213.585-.184 1.27-.39 1.774-.753a.057.057 0 0 0 .023-.043v-1.809a.052.052 0 0 0-.02-.041.053.053 0 0 0-.046-.01 20.282 20.282 0 0 1-4.709.545c-2.73 0-3.463-1.284-3.674-1.818a5.593 5.593 0 0 1-.319-1.433.053.053 0 0 1 .066-.054c1.517.363 3.072.546 4.632.546.376 0 .75 0 1.125-.01 1.57-.044 3.224-.124 4.768-.422.038-.008.077-.015.11-.024 2.435-.464 4.753-1.92 4.989-5.604.008-.145.03-1.52.03-1.67.002-.512.167-3.63-.024-5.545zm-3.748 9.195h-2.561V8.29c0-1.309-.55-1.976-1.67-1.976-1.23 0-1.846.79-1.846 2.35v3.403h-2.546V8.663c0-1.56-.617-2.35-1.848-2.35-1.112 0-1.668.668-1.67 1.977v6.218H4.822V8.102c0-1.31.337-2.35 1.011-3.12.696-.77 1.608-1.164 2.74-1.164 1.311 0 2.302.5 2.962 1.498l.638 1.06.638-1.06c.66-.999 1.65-1.498 2.96-1.498 1.13 0 2.043.395 2.74 1.164.675.77 1.012 1.81 1.012 3.12z"
+}
+
+// SettingsHint ...
+func (Mastodon) SettingsHint() string {
+	return "examples: \n  @Gargron@mastodon.social \n  https://mastodon.social/@Gargron \n  https://mastodon.social/@Gargron?boosts (boosts are included)"
+}
+
+// ChannelURL ...
+func (Mastodon) ChannelURL(externalID string) string {
+	user, host, _, _ := parseMastodonExternalID(externalID)
+	return "https://" + host + "/@" + user
+}
+
+// ContentURL ...
+func (Mastodon) ContentURL(externalID string) string {
+	return externalID // url of the status
+}
+
+// ValidateChannel ...
+func (Mastodon) ValidateChannel(data string) bool {
+	return queryMastodonAccount(data) != nil
+}
+
+// MetaData ...
+func (Mastodon) MetaData(channelID string) (string, string, string, string) {
+	acc := queryMastodonAccount(channelID)
+	if acc == nil {
+		return "", "", "", ""
+	}
+	author := acc.DisplayName
+	if author == "" {
+		author = acc.Username
+	}
+	externalID := acc.Username + "@" + acc.host + "/" + acc.ID
+	if acc.boosts {
+		externalID += "?boosts"
+	}
+
+	return author, models.KindMastodon, acc.Avatar, externalID
+}
+
+// Fetch pages backward (via max_id) until the high-water mark or the cutoff is reached
+func (Mastodon) Fetch(job *Job) error {
+	_, host, accountID, boosts := parseMastodonExternalID(job.Channel.ExternalID)
+	if host == "" || accountID == "" {
+		return errors.New("invalid mastodon channel " + job.Channel.ExternalID)
+	}
+	query := url.Values{}
+	query.Set("exclude_replies", "true")
+	query.Set("limit", "40")
+	if !boosts {
+		query.Set("exclude_reblogs", "true")
+	}
+	for page := 0; page < listingMaxPages; page++ {
+		body, err := getChannel(job, "https://"+host+"/api/v1/accounts/"+url.PathEscape(accountID)+"/statuses?"+query.Encode(), page)
+		if err != nil {
+			return err
+		}
+
+		var statuses []mastodonStatus
+		err = json.Unmarshal(body, &statuses)
+		if err != nil {
+			return err
+		}
+		if storeMastodonStatuses(job, statuses) || len(statuses) == 0 {
+			break
+		}
+		query.Set("max_id", statuses[len(statuses)-1].ID)
+	}
+	return nil
+}
+
+// storeMastodonStatuses returns true, if the high-water mark or the cutoff was reached
+func storeMastodonStatuses(job *Job, statuses []mastodonStatus) bool {
+	reachedEnd := false
+	for _, status := range statuses {
+		post := status
+		title := ""
+		if status.Reblog != nil {
+			post = *status.Reblog
+			title = "boosted @" + post.Account.Acct + ": "
+		}
+		if post.SpoilerText != "" {
+			title += "CW: " + post.SpoilerText + "\n"
+		}
+		title += htmlToText(post.Content)
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = status.CreatedAt.In(job.Loc)
+		content.ExternalID = post.URL
+		if content.ExternalID == "" {
+			content.ExternalID = post.URI
+		}
+		content.Title = title
+
+		if content.Date.Before(job.DateCutoff) {
+			reachedEnd = true
+			continue
+		}
+		if content.ExternalID == "" {
+			continue
+		}
+
+		err := createContent(job, &content)
+		if err == errKnownContent {
+			reachedEnd = true
+		}
+		if err != nil {
+			continue
+		}
+
+		for _, attachment := range post.MediaAttachments {
+			var media models.Media
+			media.ContentID = content.ID
+			media.URL = attachment.PreviewURL
+			if attachment.Type == "image" && attachment.URL != "" {
+				media.URL = attachment.URL
+			}
+			if media.URL == "" {
+				continue
+			}
+			if attachment.Description != "" {
+				media.Alt.String = attachment.Description
+				media.Alt.Valid = true
+			}
+			storeMedia(job, &media)
+		}
+		if len(post.MediaAttachments) == 0 && post.Card != nil && post.Card.Image != "" {
+			addMedia(job, content.ID, post.Card.Image)
+		}
+	}
+	return reachedEnd
+}
+
+type mastodonAccount struct {
+	ID          string
+	Username    string
+	Acct        string
+	DisplayName string `json:"display_name"`
+	Avatar      string
+
+	host   string // the server hosting the account's api
+	boosts bool
+}
+
+type mastodonStatus struct {
+	ID               string
+	CreatedAt        time.Time `json:"created_at"`
+	URL              string
+	URI              string
+	Content          string
+	SpoilerText      string `json:"spoiler_text"`
+	Account          mastodonAccount
+	Reblog           *mastodonStatus
+	MediaAttachments []struct {
+		Type        string
+		URL         string
+		PreviewURL  string `json:"preview_url"`
+		Description string
+	} `json:"media_attachments"`
+	Card *struct {
+		Image string
+	}
+}
+
+// i.e. "@Gargron@mastodon.social", "https://mastodon.social/@Gargron", "https://mastodon.social/users/Gargron"
+var mastodonHandleRegEx = regexp.MustCompile(`^@?([A-Za-z0-9_.-]+)@([A-Za-z0-9.-]+\.[A-Za-z]+)$`)
+var mastodonProfileRegEx = regexp.MustCompile(`^(?:https?:\/\/)?([A-Za-z0-9.-]+\.[A-Za-z]+)\/(?:@|users\/)([A-Za-z0-9_.-]+)\/?$`)
+
+// parseMastodonExternalID splits "user@host/accountID[?boosts]" into its parts
+func parseMastodonExternalID(externalID string) (user, host, accountID string, boosts bool) {
+	if strings.HasSuffix(externalID, "?boosts") {
+		boosts = true
+		externalID = strings.TrimSuffix(externalID, "?boosts")
+	}
+	slash := strings.LastIndex(externalID, "/")
+	if slash >= 0 {
+		accountID = externalID[slash+1:]
+		externalID = externalID[:slash]
+	}
+	if at := strings.LastIndex(externalID, "@"); at >= 0 {
+		user = externalID[:at]
+		host = externalID[at+1:]
+	}
+	return
+}
+
+// queryMastodonAccount resolves a handle or profile url (optionally suffixed with "?boosts" or " +boosts") to its account
+func queryMastodonAccount(data string) *mastodonAccount {
+	data, boosts := trimChannelOption(data, "boosts")
+
+	user, domain := "", ""
+	if res := mastodonHandleRegEx.FindStringSubmatch(data); res != nil {
+		user, domain = res[1], res[2]
+	} else if res := mastodonProfileRegEx.FindStringSubmatch(data); res != nil {
+		user, domain = res[2], res[1]
+	} else {
+		return nil
+	}
+
+	host := queryMastodonHost(user, domain)
+	body, err := httpGetBody("https://" + host + "/api/v1/accounts/lookup?acct=" + url.QueryEscape(user))
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	var acc mastodonAccount
+	if err := json.Unmarshal(body, &acc); err != nil || acc.ID == "" {
+		logging.Println(logging.Info, "mastodon account lookup failed for", user, "@", host, err)
+		return nil
+	}
+	acc.host = host
+	acc.boosts = boosts
+	return &acc
+}
+
+// queryMastodonHost uses webfinger to find the server which hosts the account,
+// since the domain of a handle can differ from the server (i.e. user@example.com served by social.example.com)
+func queryMastodonHost(user, domain string) string {
+	body, err := httpGetBody("https://" + domain + "/.well-known/webfinger?resource=" + url.QueryEscape("acct:"+user+"@"+domain))
+	if err != nil {
+		return domain
+	}
+	var finger struct {
+		Links []struct {
+			Rel  string
+			Href string
+		}
+	}
+	if err := json.Unmarshal(body, &finger); err != nil {
+		return domain
+	}
+	for _, link := range finger.Links {
+		if link.Rel != "self" {
+			continue
+		}
+		if u, err := url.Parse(link.Href); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return domain
+}
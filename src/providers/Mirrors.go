@@ -0,0 +1,276 @@
+package providers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// mirrorDeadAfter consecutive errors mark a mirror as dead, it gets revived by its next successful request
+const mirrorDeadAfter = 3
+
+// defaultMirrors are the upstream hosts of the kinds, which can be served by other hosts than the official one.
+// all hosts of a kind have to serve the same paths, i.e. invidious instances serve youtube's feeds.
+// the order is the order of preference, as long as there are no errors
+var defaultMirrors = map[string]struct {
+	healthPath string
+	hosts      []string
+}{
+	models.KindTwitter: { // nitter instances come & go, only the instance of nitter's author is a default. more can be added via MIRRORS_TWITTER
+		healthPath: "/NASA/rss", // https://github.com/zedeus/nitter/wiki/Instances
+		hosts:      []string{"nitter.net"},
+	},
+	models.KindYoutube: { // official host & invidious instances: https://docs.invidious.io/instances/
+		healthPath: "/feeds/videos.xml?channel_id=UC_x5XG1OV2P6uZZ5FSM9Ttw",
+		hosts:      []string{"www.youtube.com", "yewtu.be", "inv.nadeko.net", "invidious.nerdvpn.de"},
+	},
+	models.KindReddit: { // official hosts only, the proxies (redlib, teddit) don't serve reddit's json api
+		healthPath: "/r/golang/new.json?limit=1",
+		hosts:      []string{"www.reddit.com", "old.reddit.com"},
+	},
+}
+
+// Mirror is an upstream host of a kind along with its statistics
+type Mirror struct {
+	Host              string
+	Latency           time.Duration // moving average of the successful requests
+	Requests          int64
+	Errors            int64
+	ConsecutiveErrors int64
+	LastError         string
+	LastErrorAt       time.Time
+	LastSuccessAt     time.Time
+	Current           bool // the mirror, which is used until it fails
+}
+
+// Dead ...
+func (m Mirror) Dead() bool {
+	return m.ConsecutiveErrors >= mirrorDeadAfter
+}
+
+// ErrorRate is the share of failed requests
+func (m Mirror) ErrorRate() float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Requests)
+}
+
+// score ranks the mirrors, lower is better: errors are penalized like a slow response
+func (m *Mirror) score() time.Duration {
+	return m.Latency + time.Duration(m.ConsecutiveErrors)*10*time.Second + time.Duration(m.ErrorRate()*float64(30*time.Second))
+}
+
+// MirrorSet are the mirrors of a single kind
+type MirrorSet struct {
+	Kind    string
+	Mirrors []Mirror
+}
+
+type mirrorSet struct {
+	sync.Mutex
+	healthPath string
+	mirrors    []*Mirror
+	current    *Mirror
+}
+
+var mirrorRegistry = struct {
+	sync.Mutex
+	sets map[string]*mirrorSet
+}{sets: map[string]*mirrorSet{}}
+
+var mirrorClient = newHTTPClient()
+
+// MirrorKinds returns the kinds, which support mirrors
+func MirrorKinds() []string {
+	ret := []string{}
+	for kind := range defaultMirrors {
+		ret = append(ret, kind)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// SetMirrors replaces the mirrors of a kind (i.e. configured via env), the hosts are given in order of preference
+func SetMirrors(kind string, hosts []string) {
+	set := &mirrorSet{healthPath: defaultMirrors[kind].healthPath}
+	for _, host := range hosts {
+		host = strings.Trim(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(host), "https://"), "http://"), "/")
+		if host != "" {
+			set.mirrors = append(set.mirrors, &Mirror{Host: host})
+		}
+	}
+	if len(set.mirrors) == 0 {
+		logging.Println(logging.Warn, "no mirrors given for", kind, "- keeping the defaults")
+		return
+	}
+	mirrorRegistry.Lock()
+	defer mirrorRegistry.Unlock()
+	mirrorRegistry.sets[kind] = set
+}
+
+func mirrorsOf(kind string) *mirrorSet {
+	mirrorRegistry.Lock()
+	set, ok := mirrorRegistry.sets[kind]
+	mirrorRegistry.Unlock()
+	if !ok {
+		SetMirrors(kind, defaultMirrors[kind].hosts)
+		mirrorRegistry.Lock()
+		set = mirrorRegistry.sets[kind]
+		mirrorRegistry.Unlock()
+	}
+	return set
+}
+
+// candidates returns the mirrors in the order they should be tried:
+// the current mirror, the alive mirrors by score & finally the dead mirrors as a last resort
+func (set *mirrorSet) candidates() []*Mirror {
+	set.Lock()
+	defer set.Unlock()
+	ret := make([]*Mirror, len(set.mirrors))
+	copy(ret, set.mirrors)
+	current := func(m *Mirror) bool { return m == set.current && !m.Dead() }
+	sort.SliceStable(ret, func(i, j int) bool {
+		a, b := ret[i], ret[j]
+		if current(a) != current(b) {
+			return current(a)
+		}
+		if a.Dead() != b.Dead() {
+			return !a.Dead()
+		}
+		if a.Dead() {
+			return a.LastErrorAt.Before(b.LastErrorAt)
+		}
+		return a.score() < b.score()
+	})
+	return ret
+}
+
+func (set *mirrorSet) record(m *Mirror, latency time.Duration, err error) {
+	set.Lock()
+	defer set.Unlock()
+	m.Requests++
+	if err != nil {
+		m.Errors++
+		m.ConsecutiveErrors++
+		m.LastError = err.Error()
+		m.LastErrorAt = time.Now()
+		if set.current == m {
+			set.current = nil // fail over on the next request
+		}
+		return
+	}
+	if m.Latency == 0 {
+		m.Latency = latency
+	} else {
+		m.Latency = (m.Latency*3 + latency) / 4
+	}
+	m.ConsecutiveErrors = 0
+	m.LastSuccessAt = time.Now()
+	if set.current == nil {
+		set.current = m
+	}
+}
+
+// mirrorGet requests a path (i.e. "/golang/media/rss") from the mirrors of a kind.
+// the current mirror is kept as long as it works, otherwise the next best mirror takes over.
+// the body & the host, which served it, are returned
+func mirrorGet(kind, path string, header http.Header) ([]byte, string, error) {
+	body, _, host, err := mirrorGetWith(kind, path, header)
+	return body, host, err
+}
+
+// mirrorGetWith is mirrorGet, which returns the response headers as well
+func mirrorGetWith(kind, path string, header http.Header) ([]byte, http.Header, string, error) {
+	set := mirrorsOf(kind)
+	if set == nil {
+		return nil, nil, "", errors.New("no mirrors for " + kind)
+	}
+	var err error
+	for _, m := range set.candidates() {
+		start := time.Now()
+		var body []byte
+		var respHeader http.Header
+		body, respHeader, err = httpGetWith(mirrorClient, "https://"+m.Host+path, header)
+		if err == ErrNotModified {
+			set.record(m, time.Since(start), nil)
+			return nil, respHeader, m.Host, err
+		}
+		if !mirrorFailure(err) { // the mirror works, i.e. it answered 404 for a single channel
+			set.record(m, time.Since(start), nil)
+		} else {
+			set.record(m, time.Since(start), err)
+		}
+		if err == nil {
+			return body, respHeader, m.Host, nil
+		}
+		if status := HTTPStatus(err); status == http.StatusNotFound || status == http.StatusGone {
+			return nil, nil, m.Host, err // the channel is gone, the other mirrors won't serve it either
+		}
+		logging.Println(logging.Debug, kind, "mirror", m.Host, "failed:", err)
+	}
+	if err == nil {
+		err = errors.New("no mirrors for " + kind)
+	}
+	return nil, nil, "", err
+}
+
+// mirrorFailure tells whether an error is the mirror's fault: transport errors, server errors & rate limits
+func mirrorFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	status := HTTPStatus(err)
+	return status == 0 || status >= 500 || status == http.StatusTooManyRequests
+}
+
+// mirrorCanGet checks if any mirror of a kind serves the path
+func mirrorCanGet(kind, path string) error {
+	_, _, err := mirrorGet(kind, path, nil)
+	return err
+}
+
+// CheckMirrors requests the health path of every mirror, which updates their statistics & revives dead mirrors
+func CheckMirrors() {
+	var wg sync.WaitGroup
+	for _, kind := range MirrorKinds() {
+		set := mirrorsOf(kind)
+		set.Lock()
+		mirrors := make([]*Mirror, len(set.mirrors))
+		copy(mirrors, set.mirrors)
+		set.Unlock()
+		for _, m := range mirrors {
+			wg.Add(1)
+			go func(set *mirrorSet, m *Mirror) {
+				defer wg.Done()
+				start := time.Now()
+				_, err := httpGetBodyWith(mirrorClient, "https://"+m.Host+set.healthPath, nil)
+				set.record(m, time.Since(start), err)
+			}(set, m)
+		}
+	}
+	wg.Wait()
+}
+
+// MirrorStatus returns a snapshot of all mirrors, i.e. for the admin page
+func MirrorStatus() []MirrorSet {
+	ret := []MirrorSet{}
+	for _, kind := range MirrorKinds() {
+		set := mirrorsOf(kind)
+		set.Lock()
+		status := MirrorSet{Kind: kind}
+		for _, m := range set.mirrors {
+			mirror := *m
+			mirror.Current = m == set.current
+			status.Mirrors = append(status.Mirrors, mirror)
+		}
+		set.Unlock()
+		ret = append(ret, status)
+	}
+	return ret
+}
@@ -0,0 +1,108 @@
+package providers
+
+import (
+	"regexp"
+	"strings"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Odysee provides lbry channels via odysee's rss feeds
+type Odysee struct{}
+
+// Kind ...
+func (Odysee) Kind() string {
+	return models.KindOdysee
+}
+
+// Icon ...
+func (Odysee) Icon() string {
+	return "M12 0a12 12 0 1 0 0 24 12 12 0 0 0 0-24zm0 5a7 7 0 1 1 0 14 7 7 0 0 1 0-14z"
+}
+
+// SettingsHint ...
+func (Odysee) SettingsHint() string {
+	return "example \n  https://odysee.com/@Odysee:8"
+}
+
+// ChannelURL ...
+func (Odysee) ChannelURL(externalID string) string {
+	return "https://odysee.com/" + externalID // i.e. "@Odysee:8"
+}
+
+// ContentURL ...
+func (Odysee) ContentURL(externalID string) string {
+	return externalID // link of the video
+}
+
+// ValidateChannel ...
+func (Odysee) ValidateChannel(data string) bool {
+	externalID := extractOdyseeExternalID(data)
+	return externalID != "" && queryOdyseeFeed(externalID) != nil
+}
+
+// MetaData ...
+func (Odysee) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractOdyseeExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	f := queryOdyseeFeed(externalID)
+	if f == nil {
+		return "", "", "", ""
+	}
+	author := strings.TrimSuffix(f.Title, " on Odysee")
+	if author == "" {
+		author = externalID
+	}
+
+	return author, models.KindOdysee, f.Image, externalID
+}
+
+// Fetch ...
+func (Odysee) Fetch(job *Job) error {
+	feedURL := odyseeFeedURL(job.Channel.ExternalID)
+	body, err := httpGetBody(feedURL)
+	if err != nil {
+		return err
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		return err
+	}
+	storeFeedItems(job, f, feedURL)
+	return nil
+}
+
+// i.e. "https://odysee.com/@Odysee:8", "@Odysee:8", "lbry://@Odysee#8"
+var odyseeRegEx = regexp.MustCompile(`^(?:(?:https?:\/\/)?(?:www\.)?odysee\.com\/|lbry:\/\/)?(@[^\/?#:\s]+)(?:[:#]([0-9a-f]+))?`)
+
+func extractOdyseeExternalID(data string) string {
+	res := odyseeRegEx.FindStringSubmatch(strings.TrimSpace(data))
+	if res == nil {
+		return ""
+	}
+	ret := res[1]
+	if res[2] != "" {
+		ret += ":" + res[2]
+	}
+	return ret
+}
+
+func odyseeFeedURL(externalID string) string {
+	return "https://odysee.com/$/rss/" + externalID
+}
+
+func queryOdyseeFeed(externalID string) *parsedFeed {
+	body, err := httpGetBody(odyseeFeedURL(externalID))
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	return f
+}
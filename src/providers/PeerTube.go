@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// PeerTube provides video channels & accounts of any peertube instance via its rest api
+type PeerTube struct{}
+
+// Kind ...
+func (PeerTube) Kind() string {
+	return models.KindPeerTube
+}
+
+// Icon ...
+func (PeerTube) Icon() string {
+	return "M3 0v12l9-6zm0 12v12l9-6zm9-6v12l9-6z"
+}
+
+// SettingsHint ...
+func (PeerTube) SettingsHint() string {
+	return "examples: \n  https://framatube.org/c/framasoft_channel \n  https://framatube.org/a/framasoft"
+}
+
+// ChannelURL ...
+func (PeerTube) ChannelURL(externalID string) string {
+	return "https://" + externalID // i.e. "framatube.org/c/framasoft_channel"
+}
+
+// ContentURL ...
+func (PeerTube) ContentURL(externalID string) string {
+	return "https://" + externalID // i.e. "framatube.org/videos/watch/<uuid>"
+}
+
+// ValidateChannel ...
+func (PeerTube) ValidateChannel(data string) bool {
+	return queryPeerTubeActor(data) != nil
+}
+
+// MetaData ...
+func (PeerTube) MetaData(channelID string) (string, string, string, string) {
+	actor := queryPeerTubeActor(channelID)
+	if actor == nil {
+		return "", "", "", ""
+	}
+	author := actor.DisplayName
+	if author == "" {
+		author = actor.Name
+	}
+	profilePic := ""
+	if path := actor.avatarPath(); path != "" {
+		profilePic = "https://" + actor.host + path
+	}
+
+	return author, models.KindPeerTube, profilePic, actor.externalID
+}
+
+// Fetch ...
+func (PeerTube) Fetch(job *Job) error {
+	host, apiPath := peerTubeAPIPath(job.Channel.ExternalID)
+	body, err := getChannel(job, "https://"+host+apiPath+"/videos?sort=-publishedAt&count=50", 0)
+	if err != nil {
+		return err
+	}
+
+	type video struct {
+		UUID          string
+		Name          string
+		PublishedAt   time.Time
+		ThumbnailPath string
+		PreviewPath   string
+	}
+	type videos struct {
+		Data []video
+	}
+	var v videos
+	err = json.Unmarshal(body, &v)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range v.Data {
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = item.PublishedAt.In(job.Loc)
+		content.ExternalID = host + "/videos/watch/" + item.UUID
+		content.Title = item.Name
+
+		if content.Date.Before(job.DateCutoff) || item.UUID == "" {
+			continue
+		}
+
+		err = createContent(job, &content)
+		if err != nil {
+			continue
+		}
+
+		thumbnail := item.PreviewPath
+		if thumbnail == "" {
+			thumbnail = item.ThumbnailPath
+		}
+		if thumbnail != "" {
+			addMedia(job, content.ID, "https://"+host+thumbnail)
+		}
+	}
+	return nil
+}
+
+type peerTubeActor struct {
+	Name        string
+	DisplayName string
+	Avatar      *struct {
+		Path string
+	}
+	Avatars []struct {
+		Path  string
+		Width int
+	}
+
+	host       string
+	externalID string
+}
+
+// avatarPath returns the largest avatar, peertube < 4.2 only knows a single one
+func (a peerTubeActor) avatarPath() string {
+	path, width := "", -1
+	for _, avatar := range a.Avatars {
+		if avatar.Width > width {
+			path, width = avatar.Path, avatar.Width
+		}
+	}
+	if path == "" && a.Avatar != nil {
+		path = a.Avatar.Path
+	}
+	return path
+}
+
+// i.e. "https://framatube.org/c/framasoft_channel", "https://framatube.org/video-channels/framasoft_channel/videos", "https://framatube.org/a/framasoft"
+var peerTubeRegEx = regexp.MustCompile(`^(?:https?:\/\/)?([A-Za-z0-9.-]+\.[A-Za-z]+(?::[0-9]+)?)\/(c|a|video-channels|accounts)\/([A-Za-z0-9_.-]+(?:@[A-Za-z0-9.-]+)?)`)
+
+// peerTubeAPIPath splits the external id "host/c/name" (or "host/a/name" for accounts) into the host & its api path
+func peerTubeAPIPath(externalID string) (string, string) {
+	parts := strings.SplitN(externalID, "/", 3)
+	if len(parts) != 3 {
+		return externalID, ""
+	}
+	if parts[1] == "a" {
+		return parts[0], "/api/v1/accounts/" + url.PathEscape(parts[2])
+	}
+	return parts[0], "/api/v1/video-channels/" + url.PathEscape(parts[2])
+}
+
+func queryPeerTubeActor(data string) *peerTubeActor {
+	res := peerTubeRegEx.FindStringSubmatch(strings.TrimSpace(data))
+	if res == nil {
+		return nil
+	}
+	host, name := strings.ToLower(res[1]), res[3]
+	externalID := host + "/c/" + name
+	if res[2] == "a" || res[2] == "accounts" {
+		externalID = host + "/a/" + name
+	}
+
+	_, apiPath := peerTubeAPIPath(externalID)
+	body, err := httpGetBody("https://" + host + apiPath)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	var actor peerTubeActor
+	if err := json.Unmarshal(body, &actor); err != nil || actor.Name == "" {
+		logging.Println(logging.Info, "peertube lookup failed for", externalID, err)
+		return nil
+	}
+	actor.host = host
+	actor.externalID = externalID
+	return &actor
+}
@@ -0,0 +1,156 @@
+package providers
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+)
+
+// Podcast provides podcasts via their rss feeds (incl. the itunes extensions), episodes can be played right in the card view
+type Podcast struct{}
+
+// Kind ...
+func (Podcast) Kind() string {
+	return models.KindPodcast
+}
+
+// Icon ...
+func (Podcast) Icon() string {
+	return "M12 0a9 9 0 0 0-3.6 17.25l.3-2.1A7 7 0 1 1 19 9a7 7 0 0 1-3.7 6.15l.3 2.1A9 9 0 0 0 12 0zm0 4a5 5 0 0 0-2.95 9.04l.37-2.3A3 3 0 1 1 15 9a3 3 0 0 1-.42 1.74l.37 2.3A5 5 0 0 0 12 4zm0 3.5a1.5 1.5 0 1 0 0 3 1.5 1.5 0 0 0 0-3zM10.5 12a1.5 1.5 0 0 0-1.48 1.74l1.2 8.5A1.8 1.8 0 0 0 12 24a1.8 1.8 0 0 0 1.78-1.76l1.2-8.5A1.5 1.5 0 0 0 13.5 12z"
+}
+
+// SettingsHint ...
+func (Podcast) SettingsHint() string {
+	return "examples: \n  https://feeds.simplecast.com/54nAGcIl \n  https://changelog.com/gotime (the feed gets discovered automatically)"
+}
+
+// SupportsOpml podcast apps export their subscriptions as opml
+func (Podcast) SupportsOpml() bool {
+	return true
+}
+
+// ChannelURL ...
+func (Podcast) ChannelURL(externalID string) string {
+	return externalID // feed url
+}
+
+// ContentURL ...
+func (Podcast) ContentURL(externalID string) string {
+	return externalID // link of the episode (or the audio file itself)
+}
+
+// ValidateChannel ...
+func (Podcast) ValidateChannel(data string) bool {
+	feedURL, _ := resolveFeed(data)
+	return feedURL != ""
+}
+
+// MetaData ...
+func (Podcast) MetaData(channelID string) (string, string, string, string) {
+	feedURL, f := resolveFeed(channelID)
+	if feedURL == "" {
+		return "", "", "", ""
+	}
+	author := f.Title
+	if author == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			author = u.Host
+		}
+	}
+	profilePic := ""
+	if f.Image != "" {
+		profilePic = resolveURL(feedURL, f.Image)
+	}
+
+	return author, models.KindPodcast, profilePic, feedURL
+}
+
+// Fetch ...
+func (Podcast) Fetch(job *Job) error {
+	feedURL := job.Channel.ExternalID
+	body, err := getChannel(job, feedURL, 0)
+	if err != nil {
+		return err
+	}
+	f, err := parseFeed(body)
+	if err != nil {
+		return err
+	}
+
+	for idx, item := range f.Items {
+		if idx >= 50 {
+			break
+		}
+		audio := podcastAudio(item)
+		if audio == nil { // no episode, i.e. announcements
+			continue
+		}
+
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Title = item.Title
+		content.ExternalID = item.Link
+		if content.ExternalID == "" {
+			content.ExternalID = audio.URL
+		}
+		content.ExternalID = resolveURL(feedURL, content.ExternalID)
+		if item.Date.IsZero() { // undated episodes are dated by their first appearance
+			content.Date = time.Now().In(job.Loc)
+		} else {
+			content.Date = item.Date.In(job.Loc)
+		}
+		if content.Date.Before(job.DateCutoff) || len(content.ExternalID) > 255 {
+			continue
+		}
+
+		err = job.Services.ContentService.CreateContent(&content)
+		if err != nil { // content already exists (most likely)
+			continue
+		}
+
+		artwork := f.Image
+		if len(item.Images) > 0 {
+			artwork = item.Images[0]
+		}
+		if artwork != "" {
+			addMedia(job, content.ID, resolveURL(feedURL, artwork))
+		}
+
+		var media models.Media
+		media.ContentID = content.ID
+		media.Type = models.MediaAudio
+		media.URL = resolveURL(feedURL, audio.URL)
+		if audio.Duration > 0 {
+			media.Duration.Int64 = audio.Duration
+			media.Duration.Valid = true
+		}
+		storeMedia(job, &media)
+	}
+	return nil
+}
+
+// podcastAudio returns the first audio enclosure of an episode
+func podcastAudio(item parsedFeedItem) *parsedFeedEnclosure {
+	for idx, enc := range item.Enclosures {
+		if enc.URL == "" {
+			continue
+		}
+		if strings.HasPrefix(enc.Type, "audio/") || (enc.Type == "" && hasAudioExtension(enc.URL)) {
+			return &item.Enclosures[idx]
+		}
+	}
+	return nil
+}
+
+func hasAudioExtension(audioURL string) bool {
+	if u, err := url.Parse(audioURL); err == nil {
+		audioURL = u.Path
+	}
+	switch strings.ToLower(filepath.Ext(audioURL)) {
+	case ".mp3", ".m4a", ".aac", ".ogg", ".oga", ".opus", ".wav", ".flac":
+		return true
+	}
+	return false
+}
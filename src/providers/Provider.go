@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/database/services"
+)
+
+// Provider bundles everything vifa needs to know about a single social media kind:
+// how to fetch a channel's content, how to validate & resolve user input and how to present it
+type Provider interface {
+	// Kind is the unique name of the social media kind, i.e. "youtube"
+	Kind() string
+	// Icon returns the svg path data used in the sidebar & profile page (24x24 viewbox)
+	Icon() string
+	// SettingsHint is the tooltip shown for the channel input field on the settings page
+	SettingsHint() string
+	// ChannelURL returns the (full) URL for a given externalID (which is just the shortest possible ID to reconstruct the full URL)
+	ChannelURL(externalID string) string
+	// ContentURL resolves the final URL for a specific channel-content
+	ContentURL(externalID string) string
+	// ValidateChannel checks if the user input refers to an existing channel
+	ValidateChannel(data string) bool
+	// MetaData given a channel id, this function retrieves all the necessary meta data for a specific channel
+	// returns:
+	// author, kind, profilePic, externalID
+	MetaData(channelID string) (string, string, string, string)
+	// Fetch pulls the latest content of a single channel and stores it
+	Fetch(job *Job) error
+}
+
+// OpmlSupport is an optional interface for providers, whose channels can be imported from opml subscription lists
+type OpmlSupport interface {
+	SupportsOpml() bool
+}
+
+// ContentLabeler is implemented by providers, which label their content (i.e. youtube shorts).
+// labeled content can be hidden per account
+type ContentLabeler interface {
+	ContentLabels() []string
+}
+
+// Budgeted is implemented by providers with a limited amount of requests (i.e. instagram).
+// they pick the due channels, which are fetched now, the others are skipped.
+// all channels of the kind tell how many requests were already spent
+type Budgeted interface {
+	Schedule(due, all []models.Channel) (fetch, skipped []models.Channel)
+}
+
+// PollCapped is implemented by providers, whose channels have to be polled more often than their posting frequency suggests
+// (i.e. twitch, whose pinned live streams are only removed by the next fetch)
+type PollCapped interface {
+	MaxPollInterval() time.Duration
+}
+
+// Paced is implemented by providers, which pace the requests of all fetches of their kind (i.e. by the rate limit of an api).
+// Pace blocks the task of the kind until the next fetch may be dispatched, so the shared fetch workers don't wait for the rate limit.
+// it returns a RateLimitError, if the rate limit doesn't allow a request soon
+type Paced interface {
+	Pace() error
+}
+
+// StatusSkippedBudget is the fetch status of channels, which were skipped by a budgeted provider
+const StatusSkippedBudget = "skipped due to the daily request budget"
+
+// ErrRateLimited is matched by the errors, which are returned if the rate limit of an upstream doesn't allow a request soon.
+// it isn't a failure of the channel
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError tells when an upstream allows requests again, it matches ErrRateLimited
+type RateLimitError struct {
+	Upstream string
+	Until    time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Upstream + " is rate limited until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+// Is ...
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAt returns when a rate limited request can be retried, zero if it's unknown
+func RetryAt(err error) time.Time {
+	var limitErr *RateLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.Until
+	}
+	return time.Time{}
+}
+
+// ErrBudgetExhausted is returned by budgeted providers, once the requests of the day are spent. it isn't a failure of the channel
+var ErrBudgetExhausted = errors.New("daily request budget exhausted")
+
+// Job holds everything a provider needs to fetch the content of a single channel
+type Job struct {
+	Channel    *models.Channel
+	DateCutoff time.Time
+	Loc        *time.Location
+	Services   *services.ServiceCollection
+
+	newest     models.Content // newest content of this fetch, the next high-water mark
+	validators http.Header    // ETag & Last-Modified of this fetch
+}
+
+// known reports whether the content is older than the high-water mark of the channel, so it was seen by an earlier fetch.
+// only listings, which are sorted by date, can rely on it
+func (job *Job) known(content *models.Content) bool {
+	mark := job.Channel.LastSeenDate
+	return mark.Valid && (content.Date.Before(mark.Time) || content.ExternalID == job.Channel.LastSeenID)
+}
+
+// see keeps track of the newest content of this fetch
+func (job *Job) see(content *models.Content) {
+	if job.newest.ExternalID == "" || content.Date.After(job.newest.Date) {
+		job.newest = *content
+	}
+}
+
+// conditional returns the headers of a conditional request, which refer to the validators of the channel's last fetch
+func (job *Job) conditional() http.Header {
+	header := http.Header{}
+	if job.Channel.ETag != "" {
+		header.Set("If-None-Match", job.Channel.ETag)
+	}
+	if job.Channel.LastModified != "" {
+		header.Set("If-Modified-Since", job.Channel.LastModified)
+	}
+	return header
+}
+
+// keepValidators keeps the validators of a response to a conditional request
+func (job *Job) keepValidators(header http.Header) {
+	job.validators = header
+}
+
+// Complete applies the high-water mark & the validators of a successful fetch to the channel
+func (job *Job) Complete() {
+	if job.validators != nil {
+		job.Channel.ETag = validator(job.validators, "ETag")
+		job.Channel.LastModified = validator(job.validators, "Last-Modified")
+	}
+	if job.newest.ExternalID == "" || len(job.newest.ExternalID) > 255 {
+		return
+	}
+	if job.Channel.LastSeenDate.Valid && job.newest.Date.Before(job.Channel.LastSeenDate.Time) {
+		return
+	}
+	job.Channel.LastSeenID = job.newest.ExternalID
+	job.Channel.LastSeenDate = sql.NullTime{Time: job.newest.Date.UTC(), Valid: true}
+}
+
+// validator returns a validator header, unless it's too long to be stored
+func validator(header http.Header, key string) string {
+	if value := header.Get(key); len(value) <= 255 {
+		return value
+	}
+	return ""
+}
+
+var registry struct {
+	sync.RWMutex
+	providers []Provider
+}
+
+// Register adds a provider to the registry, which makes it available to the background tasks, routes & pages.
+// Registering the same kind twice replaces the previous provider
+func Register(p Provider) {
+	registry.Lock()
+	defer registry.Unlock()
+	for i, existing := range registry.providers {
+		if existing.Kind() == p.Kind() {
+			registry.providers[i] = p
+			return
+		}
+	}
+	registry.providers = append(registry.providers, p)
+}
+
+// Get returns the provider for the given kind or nil, if the kind is not registered
+func Get(kind string) Provider {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, p := range registry.providers {
+		if p.Kind() == kind {
+			return p
+		}
+	}
+	return nil
+}
+
+// All returns all registered providers in order of registration
+func All() []Provider {
+	registry.RLock()
+	defer registry.RUnlock()
+	ret := make([]Provider, len(registry.providers))
+	copy(ret, registry.providers)
+	return ret
+}
+
+// SupportsOpml checks if the provider accepts opml imports
+func SupportsOpml(p Provider) bool {
+	o, ok := p.(OpmlSupport)
+	return ok && o.SupportsOpml()
+}
+
+// ContentLabels returns the labels a provider may assign to its content
+func ContentLabels(p Provider) []string {
+	if l, ok := p.(ContentLabeler); ok {
+		return l.ContentLabels()
+	}
+	return nil
+}
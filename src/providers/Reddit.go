@@ -0,0 +1,346 @@
+package providers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Reddit provides subreddits via reddit's json api, either anonymously via its mirrors or via the official api (see ConfigureRedditOAuth)
+type Reddit struct{}
+
+// Kind ...
+func (Reddit) Kind() string {
+	return models.KindReddit
+}
+
+// Icon ...
+func (Reddit) Icon() string {
+	return "M12 0A12 12 0 0 0 0 12a12 12 0 0 0 12 12 12 12 0 0 0 12-12A12 12 0 0 0 12 0zm5.01 4.744c.688 0 1.25.561 1.25 1.249a1.25 1.25 0 0 1-2.498.056l-2.597-.547-.8 3.747c1.824.07 3.48.632 4.674 1.488.308-.309.73-.491 1.207-.491.968 0 1.754.786 1.754 1.754 0 .716-.435 1.333-1.01 1.614a3.111 3.111 0 0 1 .042.52c0 2.694-3.13 4.87-7.004 4.87-3.874 0-7.004-2.176-7.004-4.87 0-.183.015-.366.043-.534A1.748 1.748 0 0 1 4.028 12c0-.968.786-1.754 1.754-1.754.463 0 .898.196 1.207.49 1.207-.883 2.878-1.43 4.744-1.487l.885-4.182a.342.342 0 0 1 .14-.197.35.35 0 0 1 .238-.042l2.906.617a1.214 1.214 0 0 1 1.108-.701zM9.25 12C8.561 12 8 12.562 8 13.25c0 .687.561 1.248 1.25 1.248.687 0 1.248-.561 1.248-1.249 0-.688-.561-1.249-1.249-1.249zm5.5 0c-.687 0-1.248.561-1.248 1.25 0 .687.561 1.248 1.249 1.248.688 0 1.249-.561 1.249-1.249 0-.687-.562-1.249-1.25-1.249zm-5.466 3.99a.327.327 0 0 0-.231.094.33.33 0 0 0 0 .463c.842.842 2.484.913 2.961.913.477 0 2.105-.056 2.961-.913a.361.361 0 0 0 .029-.463.33.33 0 0 0-.464 0c-.547.533-1.684.73-2.512.73-.828 0-1.979-.196-2.512-.73a.326.326 0 0 0-.232-.095z"
+}
+
+// SettingsHint ...
+func (Reddit) SettingsHint() string {
+	return "examples: \n  https://www.reddit.com/r/funnygifs/ \n  https://www.reddit.com/r/golang+rust (multiple subreddits) \n  https://www.reddit.com/user/spez (submissions of a user) \n  https://www.reddit.com/user/spez/m/tech (multireddit) \n  https://www.reddit.com/r/golang/search?q=generics (search query) \n  append ?hot or ?top (top of the day) to change the sort mode, which is new by default"
+}
+
+// ChannelURL ...
+func (Reddit) ChannelURL(externalID string) string {
+	return "https://reddit.com" + redditListingPath(externalID, "", url.Values{})
+}
+
+// ContentURL ...
+func (Reddit) ContentURL(externalID string) string {
+	return "https://reddit.com" + externalID // permalink, i.e. "/r/golang/comments/kl3hxp/should_i_use_go_together_with_rust_in_the_same/"
+}
+
+// ValidateChannel ...
+func (Reddit) ValidateChannel(data string) bool {
+	return extractRedditExternalID(data) != ""
+}
+
+// MetaData ...
+func (Reddit) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractRedditExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	listing, sort := parseRedditExternalID(externalID)
+	author := listing
+	if strings.HasPrefix(listing, "user/") {
+		author = "u/" + strings.TrimPrefix(listing, "user/")
+	} else if parts := strings.SplitN(listing, "/search/", 2); len(parts) == 2 {
+		query, _ := url.PathUnescape(parts[1])
+		author = parts[0] + ": " + query
+	}
+	if sort != "new" {
+		author += " (" + sort + ")"
+	}
+
+	return author, models.KindReddit, "", externalID
+}
+
+// redditMaxPages limits the pagination of a listing, a page holds up to 100 posts
+const redditMaxPages = 5
+
+type redditGalleryItem struct {
+	MediaID string `json:"media_id"`
+	Caption string
+}
+
+type redditVideo struct {
+	FallbackURL string `json:"fallback_url"`
+	Duration    int64
+}
+
+type redditImage struct {
+	Source struct {
+		URL string
+	}
+}
+
+// redditMediaMetaData describes a gallery item, animated items come with a gif and/or mp4 url instead of u
+type redditMediaMetaData struct {
+	Status string
+	S      struct {
+		U   string
+		Gif string
+		Mp4 string
+	}
+}
+
+type redditPost struct {
+	Author      string
+	Title       string
+	Thumbnail   string
+	Permalink   string
+	URL         string
+	CreatedUTC  float64 `json:"created_utc"`
+	PostHint    string  `json:"post_hint"`
+	IsGallery   bool    `json:"is_gallery"`
+	GalleryData struct {
+		Items []redditGalleryItem
+	} `json:"gallery_data,omitempty"`
+	MediaMetaData map[string]json.RawMessage `json:"media_metadata"` // decoded per item, its shape varies
+	IsVideo       bool                       `json:"is_video"`
+	SecureMedia   struct {
+		RedditVideo *redditVideo `json:"reddit_video"`
+	} `json:"secure_media"`
+	Preview struct {
+		Images             []redditImage
+		RedditVideoPreview *redditVideo `json:"reddit_video_preview"` // gifs converted to videos
+	}
+	Score         int64
+	NumComments   int64  `json:"num_comments"`
+	LinkFlairText string `json:"link_flair_text"`
+	Over18        bool   `json:"over_18"`
+	Spoiler       bool
+}
+
+type redditListing struct {
+	Data struct {
+		After    string
+		Children []struct {
+			Data redditPost
+		}
+	}
+}
+
+// Fetch pages backward through the listing until the high-water mark or the cutoff is reached.
+// hot & top listings aren't sorted by date, they are paged until a page holds no recent posts at all
+func (Reddit) Fetch(job *Job) error {
+	_, sort := parseRedditExternalID(job.Channel.ExternalID)
+	after := ""
+	for page := 0; page < redditMaxPages; page++ {
+		query := url.Values{}
+		query.Set("limit", "100")
+		if after != "" {
+			query.Set("after", after)
+		}
+		header := http.Header{}
+		if page == 0 { // conditional request, the following pages are only requested if the first page changed
+			header = job.conditional()
+		}
+		body, respHeader, err := redditGet(redditListingPath(job.Channel.ExternalID, ".json", query), header)
+		if err != nil {
+			return err
+		}
+		if page == 0 {
+			job.keepValidators(respHeader)
+		}
+		var l redditListing
+		err = json.Unmarshal(body, &l)
+		if err != nil {
+			return err
+		}
+
+		recent, reachedCutoff := 0, false
+		for _, item := range l.Data.Children {
+			if storeRedditPost(job, &item.Data) {
+				recent++
+			} else {
+				reachedCutoff = true
+			}
+		}
+		after = l.Data.After
+		if after == "" || recent == 0 || (sort == "new" && reachedCutoff) {
+			break
+		}
+	}
+	return nil
+}
+
+// storeRedditPost returns false, if the post is older than the cutoff or the high-water mark of the channel
+func storeRedditPost(job *Job, post *redditPost) bool {
+	var content models.Content
+	content.ChannelID = job.Channel.ID
+	content.Date = time.Unix(int64(post.CreatedUTC), 0).UTC().In(job.Loc)
+	content.ExternalID = post.Permalink
+	content.Title = html.UnescapeString(post.Title)
+	content.Score = sql.NullInt64{Int64: post.Score, Valid: true}
+	content.Comments = sql.NullInt64{Int64: post.NumComments, Valid: true}
+	content.DiscussionURL = sql.NullString{String: "https://reddit.com" + post.Permalink, Valid: true}
+	if post.LinkFlairText != "" {
+		content.Flair = sql.NullString{String: html.UnescapeString(post.LinkFlairText), Valid: true}
+	}
+	content.Nsfw = post.Over18
+	content.Spoiler = post.Spoiler
+
+	if content.Date.Before(job.DateCutoff) {
+		return false
+	}
+
+	create := job.Services.ContentService.CreateContent
+	if _, sort := parseRedditExternalID(job.Channel.ExternalID); sort == "new" { // hot & top aren't sorted by date
+		create = func(content *models.Content) error { return createContent(job, content) }
+	}
+	err := create(&content)
+	if err != nil { // content already exists (most likely), the score & comments change over time though
+		job.Services.ContentService.UpdateContentStats(content)
+		return err != errKnownContent
+	}
+
+	preview := ""
+	if len(post.Preview.Images) > 0 {
+		preview = html.UnescapeString(post.Preview.Images[0].Source.URL)
+	}
+	video := post.SecureMedia.RedditVideo
+	if video == nil {
+		video = post.Preview.RedditVideoPreview
+	}
+
+	if video != nil && video.FallbackURL != "" {
+		var media models.Media
+		media.ContentID = content.ID
+		media.Type = models.MediaVideo
+		media.URL = video.FallbackURL
+		media.Duration = sql.NullInt64{Int64: video.Duration, Valid: video.Duration > 0}
+		media.Poster = sql.NullString{String: preview, Valid: preview != ""}
+		storeMedia(job, &media)
+	} else if post.PostHint == "image" {
+		addMedia(job, content.ID, post.URL)
+	} else if post.IsGallery {
+		for _, g := range post.GalleryData.Items {
+			var meta redditMediaMetaData
+			if err := json.Unmarshal(post.MediaMetaData[g.MediaID], &meta); err != nil || meta.Status != "valid" {
+				logging.Println(logging.Debug, "skipping gallery item", g.MediaID, "of", post.Permalink, err)
+				continue
+			}
+			var media models.Media
+			media.ContentID = content.ID
+			switch {
+			case meta.S.U != "":
+				media.URL = html.UnescapeString(meta.S.U)
+			case meta.S.Gif != "":
+				media.URL = html.UnescapeString(meta.S.Gif)
+			case meta.S.Mp4 != "":
+				media.Type = models.MediaVideo
+				media.URL = html.UnescapeString(meta.S.Mp4)
+			default:
+				continue
+			}
+			media.Alt = sql.NullString{String: g.Caption, Valid: g.Caption != ""}
+			storeMedia(job, &media)
+		}
+	} else if hasImageExtension(post.URL) {
+		addMedia(job, content.ID, post.URL)
+	} else if preview != "" {
+		addMedia(job, content.ID, preview)
+	} else if strings.HasPrefix(post.Thumbnail, "http") {
+		addMedia(job, content.ID, post.Thumbnail)
+	}
+	return true
+}
+
+// redditSortModes are the supported channel options, new is the default sort mode
+var redditSortModes = []string{"hot", "top"}
+
+// parseRedditExternalID strips the sort mode option from the external id (or user input).
+// only "?hot" & "?top" are options, "golang+top" is the multireddit of r/golang & r/top
+func parseRedditExternalID(externalID string) (listing, sort string) {
+	externalID = strings.TrimSpace(externalID)
+	for _, mode := range redditSortModes {
+		if strings.HasSuffix(externalID, "?"+mode) {
+			return strings.TrimSpace(strings.TrimSuffix(externalID, "?"+mode)), mode
+		}
+	}
+	return externalID, "new"
+}
+
+// redditListingPath builds the path of a listing, which is one of these external ids:
+// "golang" & "golang+rust" (subreddits), "user/spez" (submissions), "user/spez/m/tech" (multireddit) or "golang/search/generics"
+func redditListingPath(externalID, ext string, query url.Values) string {
+	listing, sort := parseRedditExternalID(externalID)
+	path := ""
+	switch parts := strings.SplitN(listing, "/search/", 2); {
+	case len(parts) == 2:
+		q, _ := url.PathUnescape(parts[1])
+		path = "r/" + parts[0] + "/search" + ext
+		query.Set("q", q)
+		query.Set("restrict_sr", "1")
+		query.Set("sort", sort)
+	case strings.HasPrefix(listing, "user/") && !strings.Contains(listing, "/m/"):
+		path = listing + "/submitted" + ext
+		query.Set("sort", sort)
+	case strings.HasPrefix(listing, "user/"):
+		path = listing + "/" + sort + ext
+	default:
+		path = "r/" + listing + "/" + sort + ext
+	}
+	if sort == "top" {
+		query.Set("t", "day")
+	}
+	if len(query) == 0 {
+		return "/" + path
+	}
+	return "/" + path + "?" + query.Encode()
+}
+
+// i.e. "https://www.reddit.com/r/golang", "reddit.com/r/golang+rust/top", "https://www.reddit.com/u/spez", "https://www.reddit.com/user/spez/m/tech", "https://www.reddit.com/r/golang/search?q=generics".
+// the rest of the path & fragments are ignored, i.e. "https://www.reddit.com/r/golang/comments/abc/title/#comments" is "r/golang"
+var redditRegEx = regexp.MustCompile(`^(?:(?:https?:\/\/)?(?:[a-z]+\.)?reddit\.com)?\/?(r|u|user)\/([\w+-]+)(?:\/(m\/[\w-]+))?(?:\/([a-z]+))?(?:\/[^?#\s]*)?(?:\?([^#\s]*))?(?:#\S*)?$`)
+
+// extractRedditExternalID returns the listing (see redditListingPath), followed by the sort mode option "?hot" or "?top"
+func extractRedditExternalID(data string) string {
+	data, sort := parseRedditExternalID(data)
+	res := redditRegEx.FindStringSubmatch(data)
+	if res == nil {
+		return ""
+	}
+	if sort == "new" && (res[4] == "hot" || res[4] == "top") {
+		sort = res[4]
+	}
+
+	ret := ""
+	switch {
+	case res[1] == "r" && res[4] == "search":
+		query, _ := url.ParseQuery(res[5])
+		q := strings.TrimSpace(query.Get("q"))
+		if q == "" {
+			return ""
+		}
+		ret = res[2] + "/search/" + strings.ReplaceAll(url.PathEscape(q), "+", "%2B")
+	case res[1] == "r":
+		ret = res[2]
+	case res[3] != "":
+		ret = "user/" + res[2] + "/" + res[3]
+	default:
+		ret = "user/" + res[2]
+	}
+	if sort != "new" {
+		ret += "?" + sort
+	}
+
+	if _, _, err := redditGet(redditListingPath(ret, ".json", url.Values{"limit": {"1"}}), nil); err != nil {
+		logging.Println(logging.Info, err)
+		return ""
+	}
+	return ret
+}
@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const redditOAuthAPI = "https://oauth.reddit.com"
+
+// redditDefaultInterval paces the requests, as long as the rate limit is unknown (i.e. before the first response)
+const redditDefaultInterval = 1 * time.Second
+
+// redditMaxWorkerWait is the longest wait of a request within a fetch (i.e. for the next page of a listing),
+// the first request of a fetch is reserved before it's dispatched (see Reddit.Pace)
+const redditMaxWorkerWait = 2 * time.Second
+
+// redditAPI is the official api of reddit, which is used instead of the (anonymous) mirrors if an app is configured
+var redditAPI *redditOAuth
+
+// redditOAuth requests the api with an app-only token & paces the requests by the rate limit headers of reddit
+type redditOAuth struct {
+	client    *http.Client
+	userAgent string
+
+	mu        sync.Mutex
+	remaining float64   // requests left in the current rate limit window
+	reset     time.Time // end of the current rate limit window, zero if unknown
+	next      time.Time // earliest time of the next request
+	prepaid   int       // requests, which were reserved for dispatched fetches
+}
+
+// userAgentTransport sets the user agent of all requests, which includes the token requests
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// ConfigureRedditOAuth enables the official api with the credentials of a reddit app of the type "script" or "web app" (https://www.reddit.com/prefs/apps).
+// reddit requires a unique user agent, i.e. "web:visual-feed-aggregator:v1.0 (by /u/<username>)".
+// reddit is fetched anonymously via its mirrors, if the credentials are missing
+func ConfigureRedditOAuth(clientID, clientSecret, userAgent string) bool {
+	if clientID == "" || clientSecret == "" {
+		redditAPI = nil
+		return false
+	}
+	base := &http.Client{
+		Timeout:   1 * time.Minute,
+		Transport: userAgentTransport{userAgent: userAgent, base: limitedHTTPTransport},
+	}
+	cfg := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     "https://www.reddit.com/api/v1/access_token",
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+	client := cfg.Client(context.WithValue(context.Background(), oauth2.HTTPClient, base)) // app-only token, refreshed automatically
+	client.Timeout = 1 * time.Minute
+	redditAPI = &redditOAuth{client: client, userAgent: userAgent}
+	return true
+}
+
+// redditGet requests a path (i.e. "/r/golang/new.json") from the official api, if it's configured, otherwise from the mirrors
+func redditGet(path string, header http.Header) ([]byte, http.Header, error) {
+	if redditAPI != nil {
+		return redditAPI.get(path, header)
+	}
+	body, respHeader, _, err := mirrorGetWith(models.KindReddit, path, header)
+	return body, respHeader, err
+}
+
+// Pace paces the fetches by the rate limit of the official api, the mirrors aren't paced
+func (Reddit) Pace() error {
+	if redditAPI == nil {
+		return nil
+	}
+	return redditAPI.pace()
+}
+
+func (r *redditOAuth) get(path string, header http.Header) ([]byte, http.Header, error) {
+	if err := r.take(); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequest("GET", redditOAuthAPI+path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	r.update(resp)
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.Header, err
+}
+
+// pace blocks until a request is available & reserves it for the next fetch, which uses it for its first request
+func (r *redditOAuth) pace() error {
+	wait, err := r.reserve(maxRetryWait)
+	if err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prepaid++
+	return nil
+}
+
+// take uses a request, which was reserved for a fetch, or waits shortly for the next one
+func (r *redditOAuth) take() error {
+	r.mu.Lock()
+	if r.prepaid > 0 {
+		r.prepaid--
+		r.mu.Unlock()
+		return nil
+	}
+	r.mu.Unlock()
+	wait, err := r.reserve(redditMaxWorkerWait)
+	if err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	return nil
+}
+
+// reserve returns how long to wait for the next request, the remaining requests are spread evenly until the rate limit resets.
+// once they are exhausted, the waiting requests are spread across the next window. requests, which would wait longer than maxWait,
+// fail right away, their channels are fetched later on
+func (r *redditOAuth) reserve(maxWait time.Duration) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	start := r.next
+	if start.Before(now) {
+		start = now
+	}
+	interval := redditDefaultInterval // the rate limit of the next window is unknown
+	remaining := r.remaining
+	if r.reset.After(start) {
+		if remaining >= 1 {
+			interval = time.Duration(float64(r.reset.Sub(start)) / remaining)
+			remaining--
+		} else {
+			start = r.reset
+		}
+	}
+	if wait := start.Sub(now); wait > maxWait {
+		logging.Println(logging.Debug, "reddit rate limit exhausted until", start.Format(time.RFC3339))
+		return 0, &RateLimitError{Upstream: "reddit api", Until: start}
+	}
+	r.remaining = remaining
+	r.next = start.Add(interval)
+	return start.Sub(now), nil
+}
+
+// update applies the X-Ratelimit-Remaining & X-Ratelimit-Reset (seconds) headers of a response
+func (r *redditOAuth) update(resp *http.Response) {
+	remaining, err := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reset = time.Now().Add(time.Duration(reset) * time.Second)
+	r.remaining = remaining
+	if resp.StatusCode == http.StatusTooManyRequests {
+		r.remaining = 0
+	}
+}
@@ -0,0 +1,272 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const twitchAPI = "https://api.twitch.tv/helix/"
+
+// Twitch provides streamers via the helix api: their live stream (pinned on top), their vods & clips
+type Twitch struct {
+	clientID string
+	client   *http.Client
+}
+
+// NewTwitch creates the twitch provider, which requires the credentials of a twitch app (https://dev.twitch.tv/console/apps).
+// nil is returned if they are missing
+func NewTwitch(clientID, clientSecret string) *Twitch {
+	if clientID == "" || clientSecret == "" {
+		return nil
+	}
+	cfg := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     "https://id.twitch.tv/oauth2/token",
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+	client := cfg.Client(context.WithValue(context.Background(), oauth2.HTTPClient, newHTTPClient())) // app access token, refreshed automatically
+	client.Timeout = 1 * time.Minute
+	return &Twitch{clientID: clientID, client: client}
+}
+
+// MaxPollInterval ensures new & ended live streams are noticed soon, even for streamers with few vods
+func (*Twitch) MaxPollInterval() time.Duration {
+	return 15 * time.Minute
+}
+
+// Kind ...
+func (*Twitch) Kind() string {
+	return models.KindTwitch
+}
+
+// Icon ...
+func (*Twitch) Icon() string {
+	return "M11.571 4.714h1.715v5.143H11.57zm4.715 0H18v5.143h-1.714zM6 0L1.714 4.286v15.428h5.143V24l4.286-4.286h3.428L22.286 12V0zm14.571 11.143l-3.428 3.428h-3.429l-3 3v-3H6.857V1.714h13.714z"
+}
+
+// SettingsHint ...
+func (*Twitch) SettingsHint() string {
+	return "example \n  https://www.twitch.tv/twitchdev"
+}
+
+// ChannelURL ...
+func (*Twitch) ChannelURL(externalID string) string {
+	return "https://www.twitch.tv/" + externalID // login
+}
+
+// ContentURL ...
+func (*Twitch) ContentURL(externalID string) string {
+	return "https://www.twitch.tv/" + externalID // i.e. "videos/1234", "twitchdev/clip/<slug>" or "twitchdev" (live stream)
+}
+
+// ValidateChannel ...
+func (t *Twitch) ValidateChannel(data string) bool {
+	user, err := t.queryUser(extractTwitchLogin(data))
+	if err != nil {
+		logging.Println(logging.Info, err)
+	}
+	return user != nil
+}
+
+// MetaData ...
+func (t *Twitch) MetaData(channelID string) (string, string, string, string) {
+	user, err := t.queryUser(extractTwitchLogin(channelID))
+	if user == nil {
+		logging.Println(logging.Info, err)
+		return "", "", "", ""
+	}
+
+	return user.DisplayName, models.KindTwitch, user.ProfileImageURL, user.Login
+}
+
+// Fetch ...
+func (t *Twitch) Fetch(job *Job) error {
+	user, err := t.queryUser(job.Channel.ExternalID)
+	if err != nil {
+		return err
+	}
+
+	// the parts are fetched nevertheless, a failed part fails the fetch (so the high-water mark isn't applied)
+	return joinErrors(t.fetchLiveStream(job, user), t.fetchVideos(job, user), t.fetchClips(job, user))
+}
+
+type twitchUser struct {
+	ID              string
+	Login           string
+	DisplayName     string `json:"display_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+}
+
+// fetchLiveStream replaces the pinned live stream of the channel, it is removed once the stream is over
+func (t *Twitch) fetchLiveStream(job *Job, user *twitchUser) error {
+	var streams struct {
+		Data []struct {
+			Type         string
+			Title        string
+			GameName     string    `json:"game_name"`
+			StartedAt    time.Time `json:"started_at"`
+			ThumbnailURL string    `json:"thumbnail_url"`
+		}
+	}
+	err := t.get("streams?user_id="+url.QueryEscape(user.ID), &streams)
+	if err != nil {
+		return err
+	}
+	_, err = job.Services.ContentService.RemoveLiveContent(job.Channel.ID)
+	if err != nil {
+		return err
+	}
+	if len(streams.Data) == 0 || streams.Data[0].Type != "live" {
+		return nil
+	}
+
+	stream := streams.Data[0]
+	var content models.Content
+	content.ChannelID = job.Channel.ID
+	content.Date = stream.StartedAt.In(job.Loc)
+	content.ExternalID = user.Login
+	content.Title = stream.Title
+	if stream.GameName != "" {
+		content.Title += " [" + stream.GameName + "]"
+	}
+	content.Live = true
+	err = job.Services.ContentService.CreateContent(&content)
+	if err != nil {
+		return err
+	}
+	if stream.ThumbnailURL != "" {
+		thumbnail := strings.NewReplacer("{width}", "440", "{height}", "248").Replace(stream.ThumbnailURL)
+		addMedia(job, content.ID, thumbnail+"?t="+time.Now().Format("200601021504")) // previews are cached aggressively
+	}
+	return nil
+}
+
+// fetchVideos stores the past broadcasts
+func (t *Twitch) fetchVideos(job *Job, user *twitchUser) error {
+	var videos struct {
+		Data []struct {
+			ID           string
+			Title        string
+			CreatedAt    time.Time `json:"created_at"`
+			ThumbnailURL string    `json:"thumbnail_url"`
+		}
+	}
+	err := t.get("videos?type=archive&first=50&user_id="+url.QueryEscape(user.ID), &videos)
+	if err != nil {
+		return err
+	}
+
+	for _, video := range videos.Data {
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = video.CreatedAt.In(job.Loc)
+		content.ExternalID = "videos/" + video.ID
+		content.Title = video.Title
+
+		if content.Date.Before(job.DateCutoff) {
+			continue
+		}
+
+		err = job.Services.ContentService.CreateContent(&content)
+		if err != nil {
+			continue
+		}
+
+		if video.ThumbnailURL != "" { // vods which are still being recorded have no thumbnail yet
+			addMedia(job, content.ID, strings.NewReplacer("%{width}", "320", "%{height}", "180").Replace(video.ThumbnailURL))
+		}
+	}
+	return nil
+}
+
+// fetchClips stores the clips created since the cutoff
+func (t *Twitch) fetchClips(job *Job, user *twitchUser) error {
+	var clips struct {
+		Data []struct {
+			ID           string
+			Title        string
+			CreatorName  string    `json:"creator_name"`
+			CreatedAt    time.Time `json:"created_at"`
+			ThumbnailURL string    `json:"thumbnail_url"`
+		}
+	}
+	query := url.Values{}
+	query.Set("broadcaster_id", user.ID)
+	query.Set("started_at", job.DateCutoff.UTC().Format(time.RFC3339))
+	query.Set("ended_at", time.Now().UTC().Format(time.RFC3339)) // defaults to a week after started_at otherwise
+	query.Set("first", "50")
+	err := t.get("clips?"+query.Encode(), &clips)
+	if err != nil {
+		return err
+	}
+
+	for _, clip := range clips.Data {
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Date = clip.CreatedAt.In(job.Loc)
+		content.ExternalID = user.Login + "/clip/" + clip.ID
+		content.Title = "clip by " + clip.CreatorName + ": " + clip.Title
+
+		if content.Date.Before(job.DateCutoff) {
+			continue
+		}
+
+		err = job.Services.ContentService.CreateContent(&content)
+		if err != nil {
+			continue
+		}
+
+		if clip.ThumbnailURL != "" {
+			addMedia(job, content.ID, clip.ThumbnailURL)
+		}
+	}
+	return nil
+}
+
+func (t *Twitch) queryUser(login string) (*twitchUser, error) {
+	if login == "" {
+		return nil, errors.New("invalid twitch login")
+	}
+	var users struct {
+		Data []twitchUser
+	}
+	err := t.get("users?login="+url.QueryEscape(login), &users)
+	if err != nil {
+		return nil, err
+	}
+	if len(users.Data) == 0 {
+		return nil, errors.New("unknown twitch user " + login)
+	}
+	return &users.Data[0], nil
+}
+
+func (t *Twitch) get(endpoint string, v interface{}) error {
+	body, err := httpGetBodyWith(t.client, twitchAPI+endpoint, http.Header{"Client-Id": []string{t.clientID}})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// i.e. "https://www.twitch.tv/twitchdev", "twitch.tv/twitchdev/videos", "twitchdev"
+var twitchRegEx = regexp.MustCompile(`^(?:(?:https?:\/\/)?(?:www\.|m\.)?twitch\.tv\/)?([A-Za-z0-9_]{3,25})\/?(?:[\/?#].*)?$`)
+
+func extractTwitchLogin(data string) string {
+	res := twitchRegEx.FindStringSubmatch(strings.TrimSpace(data))
+	if res == nil {
+		return ""
+	}
+	return strings.ToLower(res[1])
+}
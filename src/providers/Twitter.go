@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"encoding/xml"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Twitter provides twitter profiles via the rss feeds of nitter instances (see mirrors)
+type Twitter struct{}
+
+// Kind ...
+func (Twitter) Kind() string {
+	return models.KindTwitter
+}
+
+// Icon ...
+func (Twitter) Icon() string {
+	return "M23.953 4.57a10 10 0 01-2.825.775 4.958 4.958 0 002.163-2.723c-.951.555-2.005.959-3.127 1.184a4.92 4.92 0 00-8.384 4.482C7.69 8.095 4.067 6.13 1.64 3.162a4.822 4.822 0 00-.666 2.475c0 1.71.87 3.213 2.188 4.096a4.904 4.904 0 01-2.228-.616v.06a4.923 4.923 0 003.946 4.827 4.996 4.996 0 01-2.212.085 4.936 4.936 0 004.604 3.417 9.867 9.867 0 01-6.102 2.105c-.39 0-.779-.023-1.17-.067a13.995 13.995 0 007.557 2.209c9.053 0 13.998-7.496 13.998-13.985 0-.21 0-.42-.015-.63A9.935 9.935 0 0024 4.59z"
+}
+
+// SettingsHint ...
+func (Twitter) SettingsHint() string {
+	return "example \n  https://twitter.com/golang"
+}
+
+// ChannelURL ...
+func (Twitter) ChannelURL(externalID string) string {
+	return "https://twitter.com/" + externalID
+}
+
+// ContentURL ...
+func (Twitter) ContentURL(externalID string) string {
+	return "https://twitter.com/" + externalID // link, i.e. "<name>/status/<long number>#m"
+}
+
+// ValidateChannel ...
+func (Twitter) ValidateChannel(data string) bool {
+	return extractTwitterExternalID(data) != ""
+}
+
+// MetaData ...
+func (Twitter) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractTwitterExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	profilePic := queryTwitterProfilePic(externalID)
+
+	return externalID, models.KindTwitter, profilePic, externalID
+}
+
+// Fetch pages backward via nitter's cursors until the high-water mark or the cutoff is reached
+func (Twitter) Fetch(job *Job) error {
+	cursor := ""
+	for page := 0; page < listingMaxPages; page++ {
+		path := "/" + job.Channel.ExternalID + "/media/rss"
+		if cursor != "" {
+			path += "?cursor=" + url.QueryEscape(cursor)
+		}
+		body, header, nitterInstance, err := mirrorGetChannel(job, models.KindTwitter, path, page)
+		if err != nil {
+			return err
+		}
+		reachedEnd, err := storeTweets(job, body, nitterInstance)
+		if err != nil {
+			return err
+		}
+		cursor = header.Get("Min-Id") // cursor of the next (older) page
+		if reachedEnd || cursor == "" {
+			break
+		}
+	}
+	return nil
+}
+
+// storeTweets stores the tweets of a nitter rss feed & returns true, if the high-water mark or the cutoff was reached
+func storeTweets(job *Job, body []byte, nitterInstance string) (bool, error) {
+	type item struct {
+		XMLName     xml.Name `xml:"item"`
+		Title       string   `xml:"title"`
+		Creator     string   `xml:"creator"`
+		Description string   `xml:"description"`
+		PubDate     string   `xml:"pubDate"`
+		Link        string   `xml:"link"`
+	}
+	type chnl struct {
+		XMLName xml.Name `xml:"channel"`
+		Items   []item   `xml:"item"`
+	}
+	type feed struct {
+		XMLName xml.Name `xml:"rss"`
+		Channel chnl     `xml:"channel"`
+	}
+	var f feed
+	err := xml.Unmarshal(body, &f)
+	if err != nil {
+		return false, err
+	}
+	reachedEnd := len(f.Channel.Items) == 0
+	for _, item := range f.Channel.Items { // 20 elements per feed
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Title = item.Creator + "-" + item.Title
+		hPrefix := "http"
+		if strings.HasPrefix(item.Link, "https") {
+			hPrefix = "https"
+		}
+		content.ExternalID = strings.Replace(item.Link, hPrefix+"://"+nitterInstance+"/", "", 1)
+		date, err := parseTwitterTimeStr(item.PubDate, job.Loc)
+		if err != nil {
+			logging.Println(logging.Info, err)
+		} else {
+			content.Date = date
+		}
+
+		if content.Date.Before(job.DateCutoff) {
+			reachedEnd = true
+			continue
+		}
+
+		err = createContent(job, &content)
+		if err == errKnownContent {
+			reachedEnd = true
+		}
+		if err != nil { // content already exists
+			continue
+		}
+
+		// description contains links to media
+		res := twitterMediaRegEx.FindAllStringSubmatch(item.Description, -1)
+		for i := 0; i < len(res); i++ {
+			addMedia(job, content.ID, res[i][2])
+		}
+	}
+	return reachedEnd, nil
+}
+
+var twitterMediaRegEx = regexp.MustCompile(`(img src|video poster)="([^"]*)"`)
+
+func parseTwitterTimeStr(timestampStr string, loc *time.Location) (time.Time, error) {
+	datetime, err := time.Parse("Mon, _2 Jan 2006 15:04:05 MST", timestampStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return datetime.In(loc), nil
+}
+
+func queryTwitterProfilePic(externalID string) string {
+	data, _, err := mirrorGet(models.KindTwitter, "/"+externalID+"/rss", nil)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return ""
+	}
+
+	type image struct {
+		URL string `xml:"url"`
+	}
+	type channel struct {
+		XMLName xml.Name `xml:"channel"`
+		Image   image    `xml:"image"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Channel channel  `xml:"channel"`
+	}
+	var nitterXML rss
+	err = xml.Unmarshal(data, &nitterXML)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return ""
+	}
+
+	return nitterXML.Channel.Image.URL
+}
+
+var twitterRegEx = regexp.MustCompile(`twitter\.com\/([^\/?]+)`)
+
+func extractTwitterExternalID(data string) string {
+	res := twitterRegEx.FindAllStringSubmatch(data, -1)
+	if len(res) > 0 {
+		ret := res[0][1]
+		if err := mirrorCanGet(models.KindTwitter, "/"+ret); err != nil { // head is not supported
+			logging.Println(logging.Info, err)
+			return ""
+		}
+		return ret
+	}
+	return ""
+}
@@ -0,0 +1,338 @@
+package providers
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// Youtube provides youtube channels via their atom feeds or the youtube data api (see ConfigureYoutubeAPI)
+type Youtube struct{}
+
+// Kind ...
+func (Youtube) Kind() string {
+	return models.KindYoutube
+}
+
+// Icon ...
+func (Youtube) Icon() string {
+	return "M23.499 6.203a3.008 3.008 0 00-2.089-2.089c-1.87-.501-9.4-.501-9.4-.501s-7.509-.01-9.399.501a3.008 3.008 0 00-2.088 2.09A31.258 31.26 0 000 12.01a31.258 31.26 0 00.523 5.785 3.008 3.008 0 002.088 2.089c1.869.502 9.4.502 9.4.502s7.508 0 9.399-.502a3.008 3.008 0 002.089-2.09 31.258 31.26 0 00.5-5.784 31.258 31.26 0 00-.5-5.808zm-13.891 9.4V8.407l6.266 3.604z"
+}
+
+// SettingsHint ...
+func (Youtube) SettingsHint() string {
+	return "examples: \n  https://www.youtube.com/channel/UC_aEa8K-EOJ3D6gOs7HcyNg \n  https://www.youtube.com/user/aaarguments \n  https://www.youtube.com/@GoogleDevelopers \n  https://www.youtube.com/c/GoogleDevelopers \n  https://www.youtube.com/playlist?list=PLIivdWyY5sqJxnwJhe3etaK7utrBiPBQ2"
+}
+
+// ContentLabels shorts, live streams & premieres can be hidden per account
+func (Youtube) ContentLabels() []string {
+	return []string{"short", "live", "premiere"}
+}
+
+// SupportsOpml youtube exports its subscriptions as opml
+func (Youtube) SupportsOpml() bool {
+	return true
+}
+
+// ChannelURL ...
+func (Youtube) ChannelURL(externalID string) string {
+	if strings.HasPrefix(externalID, "playlist_id=") {
+		return "https://youtube.com/playlist?list=" + strings.TrimPrefix(externalID, "playlist_id=")
+	}
+	split := strings.Split(externalID, "=")
+	cu := strings.Replace(split[0], "_id", "", -1)
+	return "https://youtube.com/" + cu + "/" + split[1]
+}
+
+// ContentURL ...
+func (Youtube) ContentURL(externalID string) string {
+	return "https://youtu.be/" + externalID // video id, i.e. "sFxjT85dZNs"
+}
+
+// ValidateChannel ...
+func (Youtube) ValidateChannel(data string) bool {
+	return extractYoutubeExternalID(data) != ""
+}
+
+// MetaData ...
+func (Youtube) MetaData(channelID string) (string, string, string, string) {
+	externalID := extractYoutubeExternalID(channelID)
+	if externalID == "" {
+		return "", "", "", ""
+	}
+	externalIDParam := strings.Replace(externalID, "/", "=", 1)
+	if !strings.HasPrefix(externalIDParam, "channel_id") {
+		externalIDParam = strings.Replace(externalIDParam, "channel", "channel_id", 1)
+	}
+	author, title := queryYoutubeFeed(externalIDParam)
+	if strings.HasPrefix(externalIDParam, "playlist_id") && author != "" {
+		author += ": " + title
+	}
+
+	return author, models.KindYoutube, "", externalIDParam
+}
+
+// Fetch uses the youtube data api as long as there is quota left, the atom feed only holds the latest 15 videos
+func (Youtube) Fetch(job *Job) error {
+	if youtubeAPI != nil {
+		err := youtubeAPI.fetch(job)
+		if err != errYoutubeQuota {
+			return err
+		}
+		logging.Println(logging.Debug, err, "- falling back to the feed of", job.Channel.Name)
+	}
+	return fetchYoutubeFeed(job)
+}
+
+func fetchYoutubeFeed(job *Job) error {
+	body, _, _, err := mirrorGetChannel(job, models.KindYoutube, "/feeds/videos.xml?"+job.Channel.ExternalID, 0)
+	if err != nil {
+		return err
+	}
+
+	type link struct {
+		Href string `xml:"href,attr"`
+	}
+	type starRating struct {
+		Count   int64   `xml:"count,attr"`
+		Average float64 `xml:"average,attr"`
+	}
+	type statistics struct {
+		Views int64 `xml:"views,attr"`
+	}
+	type community struct {
+		StarRating *starRating `xml:"starRating"`
+		Statistics *statistics `xml:"statistics"`
+	}
+	type group struct {
+		Description string    `xml:"description"`
+		Community   community `xml:"community"`
+	}
+	type entry struct {
+		XMLName   xml.Name `xml:"entry"`
+		Title     string   `xml:"title"`
+		VideoID   string   `xml:"videoId"`
+		Published string   `xml:"published"`
+		Link      link     `xml:"link"`
+		Group     group    `xml:"group"`
+	}
+	type feed struct {
+		XMLName xml.Name `xml:"feed"`
+		Entries []entry  `xml:"entry"`
+	}
+	var f feed
+	err = xml.Unmarshal(body, &f)
+	if err != nil {
+		return err
+	}
+
+	for idx, item := range f.Entries {
+		if idx >= 50 {
+			break
+		}
+		var content models.Content
+		content.ChannelID = job.Channel.ID
+		content.Title = item.Title
+		date, err := parseYoutubeTimeStr(item.Published, job.Loc)
+		if err != nil {
+			logging.Println(logging.Info, err)
+			break
+		} else {
+			content.Date = date
+		}
+		if content.Date.Before(job.DateCutoff) { // playlists aren't sorted by date
+			continue
+		}
+		content.ExternalID = item.VideoID
+		if desc := strings.TrimSpace(item.Group.Description); desc != "" {
+			content.Description = sql.NullString{String: desc, Valid: true}
+		}
+		if stats := item.Group.Community.Statistics; stats != nil {
+			content.Views = sql.NullInt64{Int64: stats.Views, Valid: true}
+		}
+		if rating := item.Group.Community.StarRating; rating != nil && rating.Count > 0 {
+			content.Rating = sql.NullFloat64{Float64: rating.Average, Valid: true}
+		}
+		if strings.Contains(item.Link.Href, "/shorts/") {
+			content.Label = "short"
+		}
+
+		err = createYoutubeContent(job, &content)
+		if err != nil { // content already exists (most likely), the statistics change over time though
+			job.Services.ContentService.UpdateContentStats(content)
+			continue
+		}
+
+		addMedia(job, content.ID, "https://img.youtube.com/vi/"+item.VideoID+"/sddefault.jpg") // maxresdefault
+
+		if content.Label == "" { // the feed doesn't tell live streams & premieres apart from regular videos
+			if content.Label = queryYoutubeVideoLabel(item.VideoID); content.Label != "" {
+				job.Services.ContentService.UpdateContent(content)
+			}
+		}
+	}
+	return nil
+}
+
+// createYoutubeContent relies on the high-water mark for channels only, playlists aren't sorted by date
+func createYoutubeContent(job *Job, content *models.Content) error {
+	if strings.HasPrefix(job.Channel.ExternalID, "playlist_id=") {
+		return job.Services.ContentService.CreateContent(content)
+	}
+	return createContent(job, content)
+}
+
+func parseYoutubeTimeStr(timestampStr string, loc *time.Location) (time.Time, error) {
+	datetime, err := time.Parse(time.RFC3339, timestampStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return datetime.In(loc), nil
+}
+
+// youtube asks for cookie consent in the eu instead of serving its pages.
+// pages are always scraped from youtube itself, unlike the feeds they differ between the mirrors
+var youtubeClient = newHTTPClient()
+var youtubeHeader = http.Header{"Cookie": []string{"CONSENT=YES+cb; SOCS=CAI"}, "Accept-Language": []string{"en"}}
+
+// queryYoutubeVideoLabel checks the watch page of a video, whether it is (or was) a live stream or a premiere
+func queryYoutubeVideoLabel(videoID string) string {
+	body, err := httpGetBodyWith(youtubeClient, "https://www.youtube.com/watch?v="+videoID, youtubeHeader)
+	if err != nil {
+		logging.Println(logging.Debug, err)
+		return ""
+	}
+	page := string(body)
+	switch {
+	case strings.Contains(page, `"isLiveContent":true`):
+		return "live"
+	case strings.Contains(page, `"isUpcoming":true`), strings.Contains(page, `"liveBroadcastDetails"`):
+		return "premiere"
+	}
+	return ""
+}
+
+var youtubeRegEx1 = regexp.MustCompile(`youtube\.com\/(user\/[^\/\n]*)`)
+var youtubeRegEx2 = regexp.MustCompile(`(user=[^\/\n]*)`)
+var youtubeRegEx3 = regexp.MustCompile(`youtube\.com\/(channel\/[^\/\n]*)`)
+var youtubeRegEx4 = regexp.MustCompile(`(channel_id=[^\/\n]*)`)
+
+// i.e. "https://www.youtube.com/@GoogleDevelopers", "https://www.youtube.com/c/GoogleDevelopers", "@GoogleDevelopers"
+var youtubeHandleRegEx = regexp.MustCompile(`^(?:(?:https?:\/\/)?(?:www\.|m\.)?youtube\.com\/)?(@[\w.-]+|c\/[^\/?#\s]+)`)
+var youtubePlaylistRegEx = regexp.MustCompile(`youtube\.com\/.*[?&]list=([\w-]+)|(playlist_id=[\w-]+)`)
+var youtubeCanonicalRegEx = regexp.MustCompile(`<link rel="canonical" href="https:\/\/www\.youtube\.com\/channel\/(UC[\w-]{22})">`)
+var youtubeChannelIDRegEx = regexp.MustCompile(`"(?:externalId|channelId)":"(UC[\w-]{22})"`)
+
+// resolveYoutubeChannelID looks up the channel id behind a handle or vanity url
+func resolveYoutubeChannelID(path string) string {
+	body, err := httpGetBodyWith(youtubeClient, "https://www.youtube.com/"+path, youtubeHeader)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return ""
+	}
+	if res := youtubeCanonicalRegEx.FindSubmatch(body); res != nil {
+		return string(res[1])
+	}
+	if res := youtubeChannelIDRegEx.FindSubmatch(body); res != nil {
+		return string(res[1])
+	}
+	return ""
+}
+
+func extractYoutubeExternalID(data string) string {
+	data = strings.TrimSpace(data)
+	if res := youtubePlaylistRegEx.FindStringSubmatch(data); res != nil {
+		ret := res[2]
+		if ret == "" {
+			ret = "playlist_id=" + res[1]
+		}
+		if err := mirrorCanGet(models.KindYoutube, "/feeds/videos.xml?"+ret); err != nil {
+			logging.Println(logging.Info, err)
+			return ""
+		}
+		return ret
+	}
+	if res := youtubeHandleRegEx.FindStringSubmatch(data); res != nil {
+		if channelID := resolveYoutubeChannelID(res[1]); channelID != "" {
+			return "channel_id=" + channelID
+		}
+		return ""
+	}
+
+	ret := ""
+	qry := ""
+	res := youtubeRegEx1.FindAllStringSubmatch(data, -1)
+	if len(res) > 0 {
+		ret = res[0][1]
+		qry = strings.Split(ret, "/")[1]
+	}
+	if ret == "" {
+		res = youtubeRegEx2.FindAllStringSubmatch(data, -1)
+		if len(res) > 0 {
+			ret = res[0][1]
+			qry = strings.Split(ret, "=")[1]
+		}
+	}
+	if ret != "" {
+		if err := mirrorCanGet(models.KindYoutube, "/feeds/videos.xml?user="+qry); err != nil {
+			logging.Println(logging.Info, err)
+			return ""
+		}
+		return ret
+	}
+
+	if ret == "" {
+		res = youtubeRegEx3.FindAllStringSubmatch(data, -1)
+		if len(res) > 0 {
+			ret = res[0][1]
+			qry = strings.Split(ret, "/")[1]
+		}
+	}
+	if ret == "" {
+		res = youtubeRegEx4.FindAllStringSubmatch(data, -1)
+		if len(res) > 0 {
+			ret = res[0][1]
+			qry = strings.Split(ret, "=")[1]
+		}
+	}
+	if ret != "" {
+		if err := mirrorCanGet(models.KindYoutube, "/feeds/videos.xml?channel_id="+qry); err != nil {
+			logging.Println(logging.Info, err)
+			return ""
+		}
+		return ret
+	}
+
+	return ret
+}
+
+// queryYoutubeFeed returns the author & the title of a feed, the title of a channel's feed is its name
+func queryYoutubeFeed(query string) (string, string) {
+	body, _, err := mirrorGet(models.KindYoutube, "/feeds/videos.xml?"+query, nil)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return "", ""
+	}
+
+	type author struct {
+		XMLName xml.Name `xml:"author"`
+		Name    string   `xml:"name"`
+	}
+	type feed struct {
+		XMLName xml.Name `xml:"feed"`
+		Title   string   `xml:"title"`
+		Author  author   `xml:"author"`
+	}
+	data := &feed{}
+	err = xml.Unmarshal(body, data)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return "", ""
+	}
+	return data.Author.Name, data.Title
+}
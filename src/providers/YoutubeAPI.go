@@ -0,0 +1,341 @@
+package providers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+const youtubeDataAPI = "https://www.googleapis.com/youtube/v3/"
+
+// youtubeMaxPages limits the pagination of a playlist, a page holds up to 50 videos
+const youtubeMaxPages = 10
+
+var errYoutubeQuota = errors.New("daily youtube api quota exhausted")
+
+// youtubeAPI is the youtube data api v3, which is used instead of the atom feeds if an api key is configured
+var youtubeAPI *youtubeData
+
+// youtubeData requests the youtube data api & keeps track of the spent quota units, every list request costs one unit.
+// the quota resets at midnight pacific time. the spent units are only counted in memory, a restart starts over at zero -
+// youtube's quota errors mark the quota as exhausted nevertheless, then the feeds take over until the next day
+type youtubeData struct {
+	apiKey string
+	quota  int
+	client *http.Client
+
+	mu      sync.Mutex
+	day     string // pacific date of the used units
+	used    int
+	uploads map[string]string // uploads playlist id by user name
+}
+
+// ConfigureYoutubeAPI enables the youtube data api (https://console.cloud.google.com/apis/library/youtube.googleapis.com) with a daily quota of units.
+// the atom feeds are used if the api key is missing or the quota is exhausted
+func ConfigureYoutubeAPI(apiKey string, dailyQuota int) bool {
+	if apiKey == "" || dailyQuota <= 0 {
+		youtubeAPI = nil
+		return false
+	}
+	youtubeAPI = &youtubeData{
+		apiKey:  apiKey,
+		quota:   dailyQuota,
+		client:  newHTTPClient(),
+		uploads: map[string]string{},
+	}
+	return true
+}
+
+var pacificTime = loadPacificTime()
+
+func loadPacificTime() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+// take spends one unit of today's quota, if there is any left
+func (y *youtubeData) take() bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	if day := time.Now().In(pacificTime).Format("2006-01-02"); day != y.day {
+		y.day = day
+		y.used = 0
+	}
+	if y.used >= y.quota {
+		return false
+	}
+	y.used++
+	return true
+}
+
+// exhaust marks today's quota as spent, i.e. if youtube rejected a request due to the quota
+func (y *youtubeData) exhaust() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	y.used = y.quota
+}
+
+func (y *youtubeData) get(resource string, query url.Values, v interface{}) error {
+	if !y.take() {
+		return errYoutubeQuota
+	}
+	req, err := http.NewRequest("GET", youtubeDataAPI+resource+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Goog-Api-Key", y.apiKey) // not in the url, the url is part of the errors, which are shown to the users
+	resp, err := y.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Message string
+				Errors  []struct {
+					Reason string
+				}
+			}
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		for _, e := range apiErr.Error.Errors {
+			if e.Reason == "quotaExceeded" || e.Reason == "dailyLimitExceeded" {
+				y.exhaust()
+				return errYoutubeQuota
+			}
+		}
+		return &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status + " " + apiErr.Error.Message}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// uploadsPlaylist returns the playlist, which holds the videos of a channel (newest first) or the playlist itself
+func (y *youtubeData) uploadsPlaylist(externalID string) (string, error) {
+	split := strings.SplitN(externalID, "=", 2)
+	if len(split) != 2 {
+		return "", errors.New("invalid youtube channel " + externalID)
+	}
+	switch split[0] {
+	case "playlist_id":
+		return split[1], nil
+	case "channel_id":
+		return "UU" + strings.TrimPrefix(split[1], "UC"), nil
+	}
+
+	y.mu.Lock()
+	playlist, ok := y.uploads[split[1]]
+	y.mu.Unlock()
+	if ok {
+		return playlist, nil
+	}
+	var resp struct {
+		Items []struct {
+			ContentDetails struct {
+				RelatedPlaylists struct {
+					Uploads string
+				}
+			}
+		}
+	}
+	err := y.get("channels", url.Values{"part": {"contentDetails"}, "forUsername": {split[1]}}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Items) == 0 {
+		return "", errors.New("unknown youtube user " + split[1])
+	}
+	playlist = resp.Items[0].ContentDetails.RelatedPlaylists.Uploads
+	y.mu.Lock()
+	y.uploads[split[1]] = playlist
+	y.mu.Unlock()
+	return playlist, nil
+}
+
+type youtubeAPIVideo struct {
+	ID      string
+	Snippet struct {
+		Title                string
+		Description          string
+		PublishedAt          string
+		LiveBroadcastContent string // "live", "upcoming" or "none"
+	}
+	ContentDetails struct {
+		Duration string // i.e. "PT1H2M3S"
+	}
+	Statistics struct {
+		ViewCount string
+	}
+	LiveStreamingDetails *struct {
+		ActualStartTime string
+	}
+}
+
+// fetch pages backward through the uploads playlist until the high-water mark or the cutoff is reached,
+// then it looks up the details of the recent videos in batches of 50.
+// other playlists aren't sorted by date, they are paged until a page only holds known videos or videos before the cutoff
+func (y *youtubeData) fetch(job *Job) error {
+	playlist, err := y.uploadsPlaylist(job.Channel.ExternalID)
+	if err != nil {
+		return err
+	}
+	uploads := !strings.HasPrefix(job.Channel.ExternalID, "playlist_id=") // only the uploads are sorted by date
+
+	ids := []string{}
+	pageToken := ""
+	for page := 0; page < youtubeMaxPages; page++ {
+		query := url.Values{"part": {"contentDetails"}, "playlistId": {playlist}, "maxResults": {"50"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var resp struct {
+			NextPageToken string
+			Items         []struct {
+				ContentDetails struct {
+					VideoID          string
+					VideoPublishedAt string
+				}
+			}
+		}
+		if err := y.get("playlistItems", query, &resp); err != nil {
+			return err
+		}
+
+		reachedEnd := false
+		pageDone := len(resp.Items) > 0 // every video of the page is known or before the cutoff
+		for _, item := range resp.Items {
+			date, err := parseYoutubeTimeStr(item.ContentDetails.VideoPublishedAt, job.Loc)
+			if err != nil { // private or deleted videos
+				continue
+			}
+			if date.Before(job.DateCutoff) {
+				reachedEnd = true
+				continue
+			}
+			if job.known(&models.Content{Date: date, ExternalID: item.ContentDetails.VideoID}) {
+				reachedEnd = true // known videos are looked up nevertheless, their statistics change over time
+			} else {
+				pageDone = false
+			}
+			ids = append(ids, item.ContentDetails.VideoID)
+		}
+		pageToken = resp.NextPageToken
+		if pageToken == "" || (uploads && reachedEnd) || pageDone {
+			break
+		}
+	}
+
+	for start := 0; start < len(ids); start += 50 {
+		end := start + 50
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var resp struct {
+			Items []youtubeAPIVideo
+		}
+		query := url.Values{"part": {"snippet,contentDetails,statistics,liveStreamingDetails"}, "id": {strings.Join(ids[start:end], ",")}}
+		if err := y.get("videos", query, &resp); err != nil {
+			return err
+		}
+		for _, video := range resp.Items {
+			storeYoutubeAPIVideo(job, &video)
+		}
+	}
+	return nil
+}
+
+func storeYoutubeAPIVideo(job *Job, video *youtubeAPIVideo) {
+	date, err := parseYoutubeTimeStr(video.Snippet.PublishedAt, job.Loc)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return
+	}
+	var content models.Content
+	content.ChannelID = job.Channel.ID
+	content.Title = video.Snippet.Title
+	content.Date = date
+	content.ExternalID = video.ID
+	if desc := strings.TrimSpace(video.Snippet.Description); desc != "" {
+		content.Description = sql.NullString{String: desc, Valid: true}
+	}
+	if views, err := strconv.ParseInt(video.Statistics.ViewCount, 10, 64); err == nil {
+		content.Views = sql.NullInt64{Int64: views, Valid: true}
+	}
+
+	err = createYoutubeContent(job, &content)
+	if err != nil { // content already exists (most likely), the statistics change over time though
+		job.Services.ContentService.UpdateContentStats(content)
+		return
+	}
+
+	duration := parseYoutubeDuration(video.ContentDetails.Duration)
+	var media models.Media
+	media.ContentID = content.ID
+	media.URL = "https://img.youtube.com/vi/" + video.ID + "/sddefault.jpg"
+	media.Duration = sql.NullInt64{Int64: duration, Valid: duration > 0}
+	storeMedia(job, &media)
+
+	switch {
+	case video.Snippet.LiveBroadcastContent == "upcoming":
+		content.Label = "premiere"
+	case video.LiveStreamingDetails != nil:
+		content.Label = "live"
+	case duration > 0 && duration <= 180 && isYoutubeShort(video.ID): // the api doesn't tell shorts apart from regular videos
+		content.Label = "short"
+	}
+	if content.Label != "" {
+		job.Services.ContentService.UpdateContent(content)
+	}
+}
+
+// isYoutubeShort checks whether youtube serves the video as a short instead of redirecting to its watch page
+func isYoutubeShort(videoID string) bool {
+	client := &http.Client{
+		Timeout:       youtubeClient.Timeout,
+		Transport:     youtubeClient.Transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequest("HEAD", "https://www.youtube.com/shorts/"+videoID, nil)
+	if err != nil {
+		return false
+	}
+	for k, v := range youtubeHeader {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		logging.Println(logging.Debug, err)
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+var youtubeDurationRegEx = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseYoutubeDuration converts an iso 8601 duration (i.e. "PT1H2M3S") to seconds, 0 if it cannot be parsed
+func parseYoutubeDuration(str string) int64 {
+	res := youtubeDurationRegEx.FindStringSubmatch(str)
+	if res == nil {
+		return 0
+	}
+	ret := int64(0)
+	for i, factor := range []int64{24 * 60 * 60, 60 * 60, 60, 1} {
+		n, _ := strconv.ParseInt(res[i+1], 10, 64)
+		ret += n * factor
+	}
+	return ret
+}
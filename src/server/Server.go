@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"visual-feed-aggregator/src/database"
 	"visual-feed-aggregator/src/database/services"
 	"visual-feed-aggregator/src/server/middleware"
@@ -82,3 +83,26 @@ func GoogleUserInfoFromSession(s *Server, sid string) *GoogleUserInfo {
 	}
 	return &gui
 }
+
+// IsAdmin checks if the email belongs to one of the admins, which are set up via the env var ADMIN_EMAILS
+func (s *Server) IsAdmin(email string) bool {
+	for _, admin := range strings.Split(s.Env["ADMIN_EMAILS"], ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminMiddleware redirects everyone but the admins to their profile
+func (s *Server) AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		user := GoogleUserInfoFromSession(s, s.Sessions.SessionIDFromRequest(r))
+		if user == nil || !s.IsAdmin(user.Email) {
+			logging.Println(logging.Warn, "unauthorized admin request")
+			http.Redirect(rw, r, "/profile", http.StatusTemporaryRedirect)
+			return
+		}
+		next(rw, r)
+	}
+}
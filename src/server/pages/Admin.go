@@ -0,0 +1,61 @@
+package pages
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+	"visual-feed-aggregator/src/providers"
+	"visual-feed-aggregator/src/server"
+)
+
+// AdminMirrors shows the health of the upstream mirrors of all kinds
+func AdminMirrors(s *server.Server) http.HandlerFunc {
+	return RenderPage(s, func() ([]string, template.FuncMap, RenderPageLogic) {
+		pages := []string{"main-layout.html", "sidebar.html", "admin-mirrors.html"}
+		funcMap := template.FuncMap{
+			"fdate":   formatDate,
+			"percent": func(f float64) float64 { return f * 100 },
+		}
+		renderLogic := func(r *http.Request, s *server.Server, sid string, user *server.GoogleUserInfo) (map[string]interface{}, error) {
+			return map[string]interface{}{
+					"title":   s.Env["TITLE"],
+					"css":     []string{"components.css", "main-layout.css", "sidebar.css", "admin.css"},
+					"user":    user,
+					"mirrors": providers.MirrorStatus(),
+					"media":   socialMediaSvgData(""),
+				},
+				nil
+		}
+		return pages, funcMap, renderLogic
+	})
+}
+
+// recentTaskRuns is the number of runs on the task status page
+const recentTaskRuns = 100
+
+// AdminTasks shows the schedules of the background tasks & their recent runs
+func AdminTasks(s *server.Server, taskStatusFunc TaskStatusFunc) http.HandlerFunc {
+	return RenderPage(s, func() ([]string, template.FuncMap, RenderPageLogic) {
+		pages := []string{"main-layout.html", "sidebar.html", "admin-tasks.html"}
+		funcMap := template.FuncMap{
+			"fdate": formatDate,
+			"since": func(from, to time.Time) time.Duration { return to.Sub(from).Round(time.Second) },
+		}
+		renderLogic := func(r *http.Request, s *server.Server, sid string, user *server.GoogleUserInfo) (map[string]interface{}, error) {
+			runs, err := s.Services.TaskService.FindRecentTaskRuns(recentTaskRuns)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+					"title": s.Env["TITLE"],
+					"css":   []string{"components.css", "main-layout.css", "sidebar.css", "admin.css"},
+					"user":  user,
+					"tasks": taskStatusFunc(),
+					"runs":  runs,
+					"media": socialMediaSvgData(""),
+				},
+				nil
+		}
+		return pages, funcMap, renderLogic
+	})
+}
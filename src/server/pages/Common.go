@@ -1,96 +1,132 @@
 package pages
 
 import (
-	"errors"
-	"net/http"
+	"fmt"
+	"html/template"
 	"path"
 	"path/filepath"
+	"strconv"
 	"time"
 	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/providers"
 	"visual-feed-aggregator/src/server/rest"
-	"visual-feed-aggregator/src/util"
 )
 
 func socialMediaSvgData(selection string) []map[string]interface{} {
-	return []map[string]interface{}{
-		{
-			"name":    "youtube",
-			"active":  selection == "youtube",
-			"svgdata": "M23.499 6.203a3.008 3.008 0 00-2.089-2.089c-1.87-.501-9.4-.501-9.4-.501s-7.509-.01-9.399.501a3.008 3.008 0 00-2.088 2.09A31.258 31.26 0 000 12.01a31.258 31.26 0 00.523 5.785 3.008 3.008 0 002.088 2.089c1.869.502 9.4.502 9.4.502s7.508 0 9.399-.502a3.008 3.008 0 002.089-2.09 31.258 31.26 0 00.5-5.784 31.258 31.26 0 00-.5-5.808zm-13.891 9.4V8.407l6.266 3.604z",
-		},
-		{
-			"name":    "reddit",
-			"active":  selection == "reddit",
-			"svgdata": "M12 0A12 12 0 0 0 0 12a12 12 0 0 0 12 12 12 12 0 0 0 12-12A12 12 0 0 0 12 0zm5.01 4.744c.688 0 1.25.561 1.25 1.249a1.25 1.25 0 0 1-2.498.056l-2.597-.547-.8 3.747c1.824.07 3.48.632 4.674 1.488.308-.309.73-.491 1.207-.491.968 0 1.754.786 1.754 1.754 0 .716-.435 1.333-1.01 1.614a3.111 3.111 0 0 1 .042.52c0 2.694-3.13 4.87-7.004 4.87-3.874 0-7.004-2.176-7.004-4.87 0-.183.015-.366.043-.534A1.748 1.748 0 0 1 4.028 12c0-.968.786-1.754 1.754-1.754.463 0 .898.196 1.207.49 1.207-.883 2.878-1.43 4.744-1.487l.885-4.182a.342.342 0 0 1 .14-.197.35.35 0 0 1 .238-.042l2.906.617a1.214 1.214 0 0 1 1.108-.701zM9.25 12C8.561 12 8 12.562 8 13.25c0 .687.561 1.248 1.25 1.248.687 0 1.248-.561 1.248-1.249 0-.688-.561-1.249-1.249-1.249zm5.5 0c-.687 0-1.248.561-1.248 1.25 0 .687.561 1.248 1.249 1.248.688 0 1.249-.561 1.249-1.249 0-.687-.562-1.249-1.25-1.249zm-5.466 3.99a.327.327 0 0 0-.231.094.33.33 0 0 0 0 .463c.842.842 2.484.913 2.961.913.477 0 2.105-.056 2.961-.913a.361.361 0 0 0 .029-.463.33.33 0 0 0-.464 0c-.547.533-1.684.73-2.512.73-.828 0-1.979-.196-2.512-.73a.326.326 0 0 0-.232-.095z",
-		},
-		{
-			"name":    "twitter",
-			"active":  selection == "twitter",
-			"svgdata": "M23.953 4.57a10 10 0 01-2.825.775 4.958 4.958 0 002.163-2.723c-.951.555-2.005.959-3.127 1.184a4.92 4.92 0 00-8.384 4.482C7.69 8.095 4.067 6.13 1.64 3.162a4.822 4.822 0 00-.666 2.475c0 1.71.87 3.213 2.188 4.096a4.904 4.904 0 01-2.228-.616v.06a4.923 4.923 0 003.946 4.827 4.996 4.996 0 01-2.212.085 4.936 4.936 0 004.604 3.417 9.867 9.867 0 01-6.102 2.105c-.39 0-.779-.023-1.17-.067a13.995 13.995 0 007.557 2.209c9.053 0 13.998-7.496 13.998-13.985 0-.21 0-.42-.015-.63A9.935 9.935 0 0024 4.59z",
-		},
-		// { // TODO disabled due to the public insta api being limited to a few requests/day
-		// 	"name":    "instagram",
-		// 	"active":  selection == "instagram",
-		// 	"svgdata": "M12 0C8.74 0 8.333.015 7.053.072 5.775.132 4.905.333 4.14.63c-.789.306-1.459.717-2.126 1.384S.935 3.35.63 4.14C.333 4.905.131 5.775.072 7.053.012 8.333 0 8.74 0 12s.015 3.667.072 4.947c.06 1.277.261 2.148.558 2.913.306.788.717 1.459 1.384 2.126.667.666 1.336 1.079 2.126 1.384.766.296 1.636.499 2.913.558C8.333 23.988 8.74 24 12 24s3.667-.015 4.947-.072c1.277-.06 2.148-.262 2.913-.558.788-.306 1.459-.718 2.126-1.384.666-.667 1.079-1.335 1.384-2.126.296-.765.499-1.636.558-2.913.06-1.28.072-1.687.072-4.947s-.015-3.667-.072-4.947c-.06-1.277-.262-2.149-.558-2.913-.306-.789-.718-1.459-1.384-2.126C21.319 1.347 20.651.935 19.86.63c-.765-.297-1.636-.499-2.913-.558C15.667.012 15.26 0 12 0zm0 2.16c3.203 0 3.585.016 4.85.071 1.17.055 1.805.249 2.227.415.562.217.96.477 1.382.896.419.42.679.819.896 1.381.164.422.36 1.057.413 2.227.057 1.266.07 1.646.07 4.85s-.015 3.585-.074 4.85c-.061 1.17-.256 1.805-.421 2.227-.224.562-.479.96-.899 1.382-.419.419-.824.679-1.38.896-.42.164-1.065.36-2.235.413-1.274.057-1.649.07-4.859.07-3.211 0-3.586-.015-4.859-.074-1.171-.061-1.816-.256-2.236-.421-.569-.224-.96-.479-1.379-.899-.421-.419-.69-.824-.9-1.38-.165-.42-.359-1.065-.42-2.235-.045-1.26-.061-1.649-.061-4.844 0-3.196.016-3.586.061-4.861.061-1.17.255-1.814.42-2.234.21-.57.479-.96.9-1.381.419-.419.81-.689 1.379-.898.42-.166 1.051-.361 2.221-.421 1.275-.045 1.65-.06 4.859-.06l.045.03zm0 3.678c-3.405 0-6.162 2.76-6.162 6.162 0 3.405 2.76 6.162 6.162 6.162 3.405 0 6.162-2.76 6.162-6.162 0-3.405-2.76-6.162-6.162-6.162zM12 16c-2.21 0-4-1.79-4-4s1.79-4 4-4 4 1.79 4 4-1.79 4-4 4zm7.846-10.405c0 .795-.646 1.44-1.44 1.44-.795 0-1.44-.646-1.44-1.44 0-.794.646-1.439 1.44-1.439.793-.001 1.44.645 1.44 1.439z",
-		// },
+	all := providers.All()
+	ret := make([]map[string]interface{}, len(all))
+	for i, p := range all {
+		ret[i] = map[string]interface{}{
+			"name":    p.Kind(),
+			"active":  selection == p.Kind(),
+			"svgdata": p.Icon(),
+		}
 	}
+	return ret
+}
+
+func formatDate(format string, t time.Time) string {
+	return t.Format(format)
 }
 
-func httpCanGet(method, url string) error {
-	resp, err := util.HTTPRequest(method, url)
-	if err != nil {
-		return err
+// formatDuration formats seconds as "[H:]MM:SS"
+func formatDuration(seconds int64) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		return errors.New(http.StatusText(resp.StatusCode))
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// mediaOfType filters the media of a content by their type
+func mediaOfType(mediaType string, allMedia []models.Media) []models.Media {
+	ret := []models.Media{}
+	for _, m := range allMedia {
+		if m.Type == mediaType || (m.Type == "" && mediaType == models.MediaImage) {
+			ret = append(ret, m)
+		}
 	}
-	return nil
+	return ret
 }
 
-func formatDate(format string, t time.Time) string {
-	return t.Format(format)
+// brokenChannelDays is the age of the failures, after which the removal of a channel is suggested
+const brokenChannelDays = 7
+
+// channelHealth describes the failures of a channel, i.e. "404 for 14 days - remove?", empty if its last fetch succeeded
+func channelHealth(c models.Channel) string {
+	if c.FailureCount == 0 {
+		return ""
+	}
+	ret := "failing"
+	if c.HTTPStatus > 0 {
+		ret = strconv.Itoa(c.HTTPStatus)
+	}
+	if !c.FailingSince.Valid {
+		return ret
+	}
+	since := time.Since(c.FailingSince.Time)
+	switch days := int(since.Hours() / 24); {
+	case days >= brokenChannelDays:
+		ret += fmt.Sprintf(" for %d days — remove?", days)
+	case days >= 2:
+		ret += fmt.Sprintf(" for %d days", days)
+	case since >= time.Hour:
+		ret += fmt.Sprintf(" for %d hours", int(since.Hours()))
+	}
+	return ret
+}
+
+// settingsFuncMap are the template functions of the channel settings
+func settingsFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"channelHealth": channelHealth,
+	}
+}
+
+// cardFuncMap are the template functions of the card view
+func cardFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"fdate":       formatDate,
+		"fduration":   formatDuration,
+		"mediaOfType": mediaOfType,
+	}
 }
 
 // ContentURL resolves the final URL for a specific channel-content
 func ContentURL(externalID, kind string) string {
-	switch kind {
-	case models.KindYoutube:
-		return "https://youtu.be/" + externalID // video id, i.e. "sFxjT85dZNs"
-	case models.KindReddit:
-		return "https://reddit.com" + externalID // permalink, i.e. "/r/golang/comments/kl3hxp/should_i_use_go_together_with_rust_in_the_same/"
-	case models.KindTwitter:
-		return "https://twitter.com/" + externalID // link, i.e. "<name>/status/<long number>#m"
-	case models.KindInstagram:
-		return "https://instagram.com/p/" + externalID // shortcode, i.e. "<some code>"
+	if p := providers.Get(kind); p != nil {
+		return p.ContentURL(externalID)
 	}
 	return externalID
 }
 
+// resolveChannelURLs replaces the external ids of the channels with their full URL
+func resolveChannelURLs(channels []models.Channel, kind string) {
+	p := providers.Get(kind)
+	if p == nil {
+		return
+	}
+	for idx := range channels {
+		channels[idx].ExternalID = p.ChannelURL(channels[idx].ExternalID)
+	}
+}
+
 func channelDataValidatorFactory(kind string) rest.ChannelDataValidator {
-	switch kind {
-	case models.KindYoutube:
-		return YoutubeChannelValidator
-	case models.KindReddit:
-		return RedditChannelValidator
-	case models.KindTwitter:
-		return TwitterChannelValidator
-	case models.KindInstagram:
-		return InstagramChannelValidator
+	if p := providers.Get(kind); p != nil {
+		return p.ValidateChannel
 	}
 	return nil
 }
 
 func channelMetaDataProviderFactory(kind string) rest.ChannelMetaDataProvider {
-	switch kind {
-	case models.KindYoutube:
-		return YoutubeMetaDataProvider
-	case models.KindReddit:
-		return RedditMetaDataProvider
-	case models.KindTwitter:
-		return TwitterMetaDataProvider
-	case models.KindInstagram:
-		return InstagramMetaDataProvider
+	if p := providers.Get(kind); p != nil {
+		return p.MetaData
+	}
+	return nil
+}
+
+func contentLabels(kind string) []string {
+	if p := providers.Get(kind); p != nil {
+		return providers.ContentLabels(p)
 	}
 	return nil
 }
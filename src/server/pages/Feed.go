@@ -0,0 +1,173 @@
+package pages
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/providers"
+	"visual-feed-aggregator/src/server"
+	"visual-feed-aggregator/src/server/rest"
+	"visual-feed-aggregator/src/util/logging"
+
+	"github.com/tdewolff/parse/strconv"
+)
+
+// Feed renders the card view for a single social media kind
+func Feed(s *server.Server, p providers.Provider, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
+	return RenderPage(s,
+		func() ([]string, template.FuncMap, RenderPageLogic) {
+			pages := []string{"main-layout.html", "sidebar.html", "feed.html", "cardview.html"}
+			funcMap := cardFuncMap()
+			renderLogic := func(r *http.Request, s *server.Server, sid string, user *server.GoogleUserInfo) (map[string]interface{}, error) {
+				kind := p.Kind()
+
+				u, err := s.Services.UserService.GetUser(user.Email)
+				if err != nil {
+					return nil, err
+				}
+				err = s.Services.UserService.LoadUserAccountsForSocialMedia(&u, kind)
+				if err != nil {
+					return nil, err
+				}
+
+				snapshotTime := taskLastRunFunc(kind).Format("2006-01-02 15:04:05 MST")
+				csrfToken, _ := s.Sessions.Store.Get(sid, "CsrfToken")
+				return map[string]interface{}{
+						"title":    s.Env["TITLE"],
+						"csrf":     csrfToken,
+						"css":      []string{"components.css", "main-layout.css", "sidebar.css", "cardview.css", "carousel.css"},
+						"js":       []string{"cardview-header.js", "carousel.js"},
+						"snapshot": snapshotTime,
+						"user":     user,
+						"accounts": u.Accounts,
+						"kind":     kind,
+						"media":    socialMediaSvgData(kind),
+					},
+					nil
+			}
+			return pages, funcMap, renderLogic
+		})
+}
+
+// Settings renders the account & channel settings for a single social media kind
+func Settings(s *server.Server, p providers.Provider) http.HandlerFunc {
+	return RenderPage(s,
+		func() ([]string, template.FuncMap, RenderPageLogic) {
+			pages := []string{"main-layout.html", "sidebar.html", "settings.html", "generic-settings.html"}
+			renderLogic := func(r *http.Request, s *server.Server, sid string, user *server.GoogleUserInfo) (map[string]interface{}, error) {
+				kind := p.Kind()
+
+				u, err := s.Services.UserService.GetUser(user.Email)
+				if err != nil {
+					return nil, err
+				}
+				err = s.Services.UserService.LoadUserAccountsForSocialMedia(&u, kind)
+				if err != nil {
+					return nil, err
+				}
+				channels := []models.Channel{}
+				if len(u.Accounts) > 0 {
+					channels, err = s.Services.ChannelService.FindChannelsByAccountIDAndKind(u.Accounts[0].ID, kind)
+					if err != nil {
+						logging.Println(logging.Info, err)
+					}
+					resolveChannelURLs(channels, kind)
+				}
+
+				opml := providers.SupportsOpml(p)
+				js := []string{"settings.js"}
+				if opml {
+					js = append(js, "opml-settings.js")
+				}
+				csrfToken, _ := s.Sessions.Store.Get(sid, "CsrfToken")
+				return map[string]interface{}{
+						"title":        s.Env["TITLE"],
+						"csrf":         csrfToken,
+						"css":          []string{"components.css", "main-layout.css", "sidebar.css", "settings.css"},
+						"js":           js,
+						"opml":         opml,
+						"labels":       providers.ContentLabels(p),
+						"user":         user,
+						"accounts":     u.Accounts,
+						"kind":         kind,
+						"channelHint":  p.SettingsHint(),
+						"channels":     channels,
+						"channelCount": len(channels),
+						"media":        socialMediaSvgData(kind),
+					},
+					nil
+			}
+			return pages, settingsFuncMap(), renderLogic
+		})
+}
+
+// OpmlUpload imports all channels of an opml subscription list into an account
+func OpmlUpload(s *server.Server, refresh rest.ChannelRefresher) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1024 * 10)
+		accountIDStr := r.FormValue("accountID")
+		accountID, _ := strconv.ParseInt([]byte(accountIDStr))
+		p := providers.Get(r.FormValue("kind"))
+		if p == nil || !providers.SupportsOpml(p) {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("opml-file")
+		if err != nil {
+			json.NewEncoder(rw).Encode(err)
+			logging.Println(logging.Error, err)
+			return
+		}
+
+		fileBytes, err := ioutil.ReadAll(file)
+		if err != nil {
+			json.NewEncoder(rw).Encode(err)
+			logging.Println(logging.Error, err)
+			return
+		}
+
+		type Outline struct {
+			XMLName  xml.Name  `xml:"outline"`
+			Outlines []Outline `xml:"outline"`
+			Title    string    `xml:"title,attr"`
+			URL      string    `xml:"xmlUrl,attr"`
+		}
+		type Body struct {
+			XMLName xml.Name `xml:"body"`
+			Outline Outline  `xml:"outline"`
+		}
+		type Opml struct {
+			XMLName xml.Name `xml:"opml"`
+			Body    Body     `xml:"body"`
+		}
+
+		xmlData := []byte(strings.ToValidUTF8(string(fileBytes), ""))
+
+		var opml Opml
+		decoder := xml.NewDecoder(strings.NewReader(string(xmlData)))
+		decoder.Strict = false
+		err = decoder.Decode(&opml)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+		created := []models.Channel{}
+		for _, o := range opml.Body.Outline.Outlines {
+			c, isNew, err := rest.DoAddChannel(s, accountID, o.URL, p.MetaData)
+			if err != nil {
+				logging.Println(logging.Info, o.URL, err)
+			} else if isNew {
+				created = append(created, c)
+			}
+		}
+		if len(created) > 0 {
+			refresh(created) // all new channels at once, in a single run per kind
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+}
@@ -20,11 +20,50 @@ func Cards(s *server.Server, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
 	var tpl *template.Template
 	var tplErr error
 	return func(rw http.ResponseWriter, r *http.Request) {
-		// Caching via (resp) Last-Modified --> (req)If-Modified-Since --> conditional resp
-		timeLayout := "Mon, 02 Jan 2006 15:04:05 GMT"
 		accountID := r.URL.Query().Get("id")
 		accountKind := r.URL.Query().Get("kind")
+
+		sid := s.Sessions.SessionIDFromRequest(r)
+		user := server.GoogleUserInfoFromSession(s, sid)
+
+		u, err := s.Services.UserService.GetUser(user.Email)
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+		accID := int64(-1)
+		if accountID == "*" {
+			err = s.Services.UserService.LoadUserAccountsForSocialMedia(&u, accountKind)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				logging.Println(logging.Error, err)
+				return
+			}
+		} else {
+			accID, err = strconv.ParseInt(accountID, 10, 64)
+			if err != nil {
+				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				logging.Println(logging.Error, err)
+				return
+			}
+			if acc, err := s.Services.AccountService.GetAccount(accID); err == nil && acc.UserID == u.ID {
+				u.Accounts = []models.Account{acc}
+			}
+		}
+
+		// Caching via (resp) Last-Modified --> (req)If-Modified-Since --> conditional resp.
+		// the cards change with the fetches of the kind, with the settings of the accounts & with the nsfw mode of the user
+		timeLayout := "Mon, 02 Jan 2006 15:04:05 GMT"
 		lastModified := taskLastRunFunc(accountKind)
+		if u.SettingsUpdatedAt.Valid && u.SettingsUpdatedAt.Time.After(lastModified) {
+			lastModified = u.SettingsUpdatedAt.Time
+		}
+		for _, acc := range u.Accounts {
+			if acc.SettingsUpdatedAt.Valid && acc.SettingsUpdatedAt.Time.After(lastModified) {
+				lastModified = acc.SettingsUpdatedAt.Time
+			}
+		}
 		ifModifiedSinceStr := r.Header.Get("If-Modified-Since")
 		if ifModifiedSinceStr != "" {
 			ifModifiedSince, err := time.Parse(timeLayout, ifModifiedSinceStr)
@@ -38,10 +77,7 @@ func Cards(s *server.Server, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
 		}
 
 		init.Do(func() {
-			funcMap := template.FuncMap{
-				"fdate": formatDate,
-			}
-			tpl, tplErr = template.New("cardview.html").Funcs(funcMap).ParseFiles(templates("cardview.html")...)
+			tpl, tplErr = template.New("cardview.html").Funcs(cardFuncMap()).ParseFiles(templates("cardview.html")...)
 			if tplErr == nil {
 				tpl, tplErr = tpl.Parse(`{{template "cards" .}}`)
 			}
@@ -52,15 +88,6 @@ func Cards(s *server.Server, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
 			return
 		}
 
-		sid := s.Sessions.SessionIDFromRequest(r)
-		user := server.GoogleUserInfoFromSession(s, sid)
-
-		u, err := s.Services.UserService.GetUser(user.Email)
-		if err != nil {
-			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			logging.Println(logging.Error, err)
-			return
-		}
 		// paging
 		pageStr := r.URL.Query().Get("page")
 		page, err := strconv.ParseInt(pageStr, 10, 64)
@@ -79,33 +106,11 @@ func Cards(s *server.Server, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
 		}
 		page *= count
 		// content retrieval
-		var contents []models.Content
-		if accountID == "*" {
-			err = s.Services.UserService.LoadUserAccountsForSocialMedia(&u, accountKind)
-			if err != nil {
-				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				logging.Println(logging.Error, err)
-				return
-			}
-			contents, err = s.Services.ContentService.LoadContentFor(u.ID, accountKind, -1, page, count)
-			if err != nil {
-				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				logging.Println(logging.Error, err)
-				return
-			}
-		} else {
-			accID, err := strconv.ParseInt(accountID, 10, 64)
-			if err != nil {
-				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				logging.Println(logging.Error, err)
-				return
-			}
-			contents, err = s.Services.ContentService.LoadContentFor(u.ID, accountKind, accID, page, count)
-			if err != nil {
-				http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-				logging.Println(logging.Error, err)
-				return
-			}
+		contents, err := s.Services.ContentService.LoadContentFor(u.ID, accountKind, accID, page, count)
+		if err != nil {
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			logging.Println(logging.Error, err)
+			return
 		}
 		// content adjustment
 		loc := time.Now().Location()
@@ -118,6 +123,7 @@ func Cards(s *server.Server, taskLastRunFunc TaskLastRunFunc) http.HandlerFunc {
 		var buf bytes.Buffer
 		err = tpl.Execute(io.Writer(&buf), map[string]interface{}{
 			"contents": contents,
+			"nsfwMode": u.NsfwMode,
 		})
 		if err != nil {
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -140,7 +146,7 @@ func SettingAccountSelection(s *server.Server) http.HandlerFunc {
 	var tplErr error
 	return func(rw http.ResponseWriter, r *http.Request) {
 		init.Do(func() {
-			tpl, tplErr = tpl.ParseFiles(templates("generic-settings.html")...)
+			tpl, tplErr = template.New("generic-settings.html").Funcs(settingsFuncMap()).ParseFiles(templates("generic-settings.html")...)
 			if tplErr == nil {
 				tpl, tplErr = tpl.Parse(`{{template "account-select" .}}`)
 			}
@@ -195,7 +201,7 @@ func SettingChannelTable(s *server.Server) http.HandlerFunc {
 	var tplErr error
 	return func(rw http.ResponseWriter, r *http.Request) {
 		init.Do(func() {
-			tpl, tplErr = tpl.ParseFiles(templates("generic-settings.html")...)
+			tpl, tplErr = template.New("generic-settings.html").Funcs(settingsFuncMap()).ParseFiles(templates("generic-settings.html")...)
 			if tplErr == nil {
 				tpl, tplErr = tpl.Parse(`{{template "channel-table" .}}`)
 			}
@@ -229,6 +235,7 @@ func SettingChannelTable(s *server.Server) http.HandlerFunc {
 				logging.Println(logging.Error, err)
 				return
 			}
+			resolveChannelURLs(channels, channelData.Kind)
 		}
 
 		var buf bytes.Buffer
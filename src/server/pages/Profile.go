@@ -4,11 +4,14 @@ import (
 	"html/template"
 	"net/http"
 	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/providers"
 	"visual-feed-aggregator/src/server"
 	"visual-feed-aggregator/src/util/logging"
 )
 
 type stats struct {
+	Kind     string
+	Icon     string
 	Accounts int
 	Channels int
 	Contents int
@@ -23,22 +26,28 @@ func Profile(s *server.Server) http.HandlerFunc {
 			if err != nil {
 				return nil, err
 			}
-			sts := []stats{
-				getStats(s, u, models.KindYoutube),
-				getStats(s, u, models.KindReddit),
-				getStats(s, u, models.KindTwitter),
+			var overall stats
+			sts := []stats{}
+			for _, p := range providers.All() {
+				st := getStats(s, u, p.Kind())
+				st.Icon = p.Icon()
+				overall.Accounts += st.Accounts
+				overall.Channels += st.Channels
+				overall.Contents += st.Contents
+				sts = append(sts, st)
 			}
-			sts = append(sts, stats{
-				sts[0].Accounts + sts[1].Accounts + sts[2].Accounts,
-				sts[0].Channels + sts[1].Channels + sts[2].Channels,
-				sts[0].Contents + sts[1].Contents + sts[2].Contents,
-			})
+			csrfToken, _ := s.Sessions.Store.Get(sid, "CsrfToken")
 			return map[string]interface{}{
-					"title": s.Env["TITLE"],
-					"css":   []string{"components.css", "main-layout.css", "sidebar.css", "profile.css"},
-					"user":  user,
-					"stats": sts,
-					"media": socialMediaSvgData(""),
+					"title":   s.Env["TITLE"],
+					"csrf":    csrfToken,
+					"css":     []string{"components.css", "main-layout.css", "sidebar.css", "settings.css", "profile.css"},
+					"js":      []string{"profile.js"},
+					"user":    user,
+					"nsfw":    u.NsfwMode,
+					"admin":   s.IsAdmin(u.Email),
+					"stats":   sts,
+					"overall": overall,
+					"media":   socialMediaSvgData(""),
 				},
 				nil
 		}
@@ -47,7 +56,7 @@ func Profile(s *server.Server) http.HandlerFunc {
 }
 
 func getStats(s *server.Server, u models.User, kind string) stats {
-	var ret stats
+	ret := stats{Kind: kind}
 	err := s.Services.UserService.LoadUserAccountsForSocialMedia(&u, kind)
 	if err != nil {
 		logging.Println(logging.Info, err)
@@ -4,20 +4,26 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"visual-feed-aggregator/src/providers"
 	"visual-feed-aggregator/src/server"
 	"visual-feed-aggregator/src/server/middleware"
 	"visual-feed-aggregator/src/server/rest"
+	"visual-feed-aggregator/src/tasks"
 	"visual-feed-aggregator/src/util/logging"
 
 	"github.com/julienschmidt/httprouter"
 )
 
-// TaskLastRunFunc retrieves the last-run time, used for caching purposes (last-modified)
+// TaskLastRunFunc retrieves the snapshot time of a kind (the last run, which changed content), used for caching purposes (last-modified)
 type TaskLastRunFunc func(kind string) time.Time
 
+// TaskStatusFunc retrieves the state of all background tasks, for the task status page
+type TaskStatusFunc func() []tasks.TaskStatus
+
 // SetupRoutes sets up all routes.
 // This is the central place for all routes!
-func SetupRoutes(s *server.Server, router *httprouter.Router, taskLastRunFunc TaskLastRunFunc) {
+// The feed & settings pages are set up for every registered provider, so providers have to be registered beforehand
+func SetupRoutes(s *server.Server, router *httprouter.Router, taskLastRunFunc TaskLastRunFunc, refresh rest.ChannelRefresher, taskStatusFunc TaskStatusFunc) {
 	middlewares := []func(http.HandlerFunc) http.HandlerFunc{
 		s.Sessions.SessionMiddleware,
 		middleware.AutoDetectContentType,
@@ -44,21 +50,20 @@ func SetupRoutes(s *server.Server, router *httprouter.Router, taskLastRunFunc Ta
 		middleware.GzipMiddleware,
 		middleware.Recover,
 	}
+	middlewaresAdmin := append([]func(http.HandlerFunc) http.HandlerFunc{s.AdminMiddleware}, middlewaresEx...)
 
 	// routes
 	router.HandlerFunc(http.MethodGet, "/", use(Welcome(s), middlewares...))
 	router.HandlerFunc(http.MethodGet, "/profile", use(Profile(s), middlewaresEx...))
 	router.HandlerFunc(http.MethodGet, "/logout", use(Logout(s), middlewaresEx...))
+	router.HandlerFunc(http.MethodGet, "/admin-mirrors", use(AdminMirrors(s), middlewaresAdmin...))
+	router.HandlerFunc(http.MethodGet, "/admin-tasks", use(AdminTasks(s, taskStatusFunc), middlewaresAdmin...))
 
-	router.HandlerFunc(http.MethodGet, "/youtube", use(Youtube(s, taskLastRunFunc), middlewaresEx...))
-	router.HandlerFunc(http.MethodGet, "/youtube-settings", use(YoutubeSettings(s), middlewaresEx...))
-	router.HandlerFunc(http.MethodPost, "/opmlupload", use(YoutubeOpmlUpload(s), middlewaresExCSRF...))
-	router.HandlerFunc(http.MethodGet, "/reddit", use(Reddit(s, taskLastRunFunc), middlewaresEx...))
-	router.HandlerFunc(http.MethodGet, "/reddit-settings", use(RedditSettings(s), middlewaresEx...))
-	router.HandlerFunc(http.MethodGet, "/twitter", use(Twitter(s, taskLastRunFunc), middlewaresEx...))
-	router.HandlerFunc(http.MethodGet, "/twitter-settings", use(TwitterSettings(s), middlewaresEx...))
-	// router.HandlerFunc(http.MethodGet, "/instagram", use(Instagram(s, taskLastRunFunc TaskLastRunFunc), middlewaresEx...)) // TODO disabled due to the public insta api being limited to a few requests/day
-	// router.HandlerFunc(http.MethodGet, "/instagram-settings", use(InstagramSettings(s), middlewaresEx...))
+	for _, p := range providers.All() {
+		router.HandlerFunc(http.MethodGet, "/"+p.Kind(), use(Feed(s, p, taskLastRunFunc), middlewaresEx...))
+		router.HandlerFunc(http.MethodGet, "/"+p.Kind()+"-settings", use(Settings(s, p), middlewaresEx...))
+	}
+	router.HandlerFunc(http.MethodPost, "/opmlupload", use(OpmlUpload(s, refresh), middlewaresExCSRF...))
 
 	router.HandlerFunc(http.MethodGet, "/partial-renderer/cards", use(Cards(s, taskLastRunFunc), middlewaresEx...))
 	router.HandlerFunc(http.MethodPost, "/partial-renderer/settings-account-selection", use(SettingAccountSelection(s), middlewaresEx...))
@@ -66,10 +71,13 @@ func SetupRoutes(s *server.Server, router *httprouter.Router, taskLastRunFunc Ta
 
 	router.HandlerFunc(http.MethodPost, "/api/v1/account", use(rest.AddAccount(s), middlewaresExCSRF...))
 	router.HandlerFunc(http.MethodDelete, "/api/v1/account", use(rest.DeleteAccount(s), middlewaresExCSRF...))
-	router.HandlerFunc(http.MethodPost, "/api/v1/channel", use(rest.AddChannel(s, channelMetaDataProviderFactory), middlewaresExCSRF...))
+	router.HandlerFunc(http.MethodPost, "/api/v1/user/nsfw", use(rest.UpdateNsfwMode(s), middlewaresExCSRF...))
+	router.HandlerFunc(http.MethodPost, "/api/v1/account/filter", use(rest.UpdateAccountFilter(s, contentLabels), middlewaresExCSRF...))
+	router.HandlerFunc(http.MethodPost, "/api/v1/channel", use(rest.AddChannel(s, channelMetaDataProviderFactory, refresh), middlewaresExCSRF...))
 	router.HandlerFunc(http.MethodDelete, "/api/v1/channel", use(rest.DeleteChannel(s), middlewaresExCSRF...))
 	router.HandlerFunc(http.MethodHead, "/api/v1/channel", use(rest.ValidateChannel(s, channelDataValidatorFactory), middlewaresExCSRF...))
 	router.HandlerFunc(http.MethodGet, "/api/v1/content", use(rest.ContentCount(s), middlewaresExCSRF...))
+	router.HandlerFunc(http.MethodPost, "/api/v1/refresh", use(rest.RefreshChannels(s, refresh), middlewaresExCSRF...))
 
 	router.HandlerFunc(http.MethodGet, "/login/oauth2", use(Oauth2LoginHandler(s), middlewares...))
 	router.HandlerFunc(http.MethodGet, "/login/oauth2/callback",
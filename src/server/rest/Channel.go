@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"visual-feed-aggregator/src/database/models"
 	"visual-feed-aggregator/src/server"
 	"visual-feed-aggregator/src/util/logging"
 )
@@ -50,8 +51,8 @@ func ValidateChannel(s *server.Server, f ChannelDataValidatorFactory) http.Handl
 //		"AccountID": "<AccountID>",
 //		"kind": "<kind>",
 // }
-// to create a new association
-func AddChannel(s *server.Server, f ChannelMetaDataProviderFactory) http.HandlerFunc {
+// to create a new association, new channels are fetched right away
+func AddChannel(s *server.Server, f ChannelMetaDataProviderFactory, refresh ChannelRefresher) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
 		var channelData struct {
 			ChannelID string
@@ -67,43 +68,47 @@ func AddChannel(s *server.Server, f ChannelMetaDataProviderFactory) http.Handler
 
 		accountID, _ := strconv.ParseInt(channelData.AccountID, 10, 64)
 
-		if err := DoAddChannel(s, accountID, channelData.ChannelID, f(channelData.Kind)); err != nil {
+		c, created, err := DoAddChannel(s, accountID, channelData.ChannelID, f(channelData.Kind))
+		if err != nil {
 			logging.Println(logging.Error, err)
 			rw.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		if created {
+			refresh([]models.Channel{c}) // the first fetch of a new channel doesn't wait for the background task
+		}
 		rw.WriteHeader(http.StatusOK)
 	}
 }
 
-// DoAddChannel ...
-func DoAddChannel(s *server.Server, accountID int64, channelID string, f ChannelMetaDataProvider) error {
+// DoAddChannel adds a channel to an account, it returns the channel & whether it was created
+func DoAddChannel(s *server.Server, accountID int64, channelID string, f ChannelMetaDataProvider) (models.Channel, bool, error) {
 	author, kind, profilePic, externalID := f(channelID)
 	if author == "" {
-		return errors.New("author not found")
+		return models.Channel{}, false, errors.New("author not found")
 	}
 
-	c, _, err := s.Services.ChannelService.CreateChannelIfNotExists(author, kind, profilePic, externalID)
+	c, created, err := s.Services.ChannelService.CreateChannelIfNotExists(author, kind, profilePic, externalID)
 	if err != nil {
-		return err
+		return c, created, err
 	}
 
 	hasToAddChannel := !s.Services.AccountService.HasChannel(accountID, c.ID)
 
 	if !hasToAddChannel {
-		return nil
+		return c, created, nil
 	}
 
 	acc, err := s.Services.AccountService.GetAccount(accountID)
 	if err != nil {
-		return err
+		return c, created, err
 	}
 	err = s.Services.AccountService.AddChannel(&acc, c)
 	if err != nil {
-		return err
+		return c, created, err
 	}
 
-	return nil
+	return c, created, nil
 }
 
 // DeleteChannel ...
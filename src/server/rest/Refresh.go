@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/providers"
+	"visual-feed-aggregator/src/server"
+	"visual-feed-aggregator/src/util/logging"
+)
+
+// ChannelRefresher fetches channels right away, it returns how many channels are fetched
+// & a channel, which is closed once they are done
+type ChannelRefresher func(channels []models.Channel) (int, <-chan struct{})
+
+// refreshCooldown is the time a user has to wait between two refreshes
+const refreshCooldown = 1 * time.Minute
+
+// refreshWait is the longest time a refresh request waits for the fetches, they continue in the background afterwards
+const refreshWait = 20 * time.Second
+
+var lastUserRefresh = struct {
+	sync.Mutex
+	byUser map[int64]time.Time
+}{byUser: map[int64]time.Time{}}
+
+// startUserRefresh returns the remaining cooldown of a user, or starts a new one
+func startUserRefresh(userID int64) time.Duration {
+	lastUserRefresh.Lock()
+	defer lastUserRefresh.Unlock()
+	now := time.Now()
+	if remaining := lastUserRefresh.byUser[userID].Add(refreshCooldown).Sub(now); remaining > 0 {
+		return remaining
+	}
+	lastUserRefresh.byUser[userID] = now
+	return 0
+}
+
+// RefreshChannels fetches the channels of a kind, of an account or a single channel of an account right away
+// {
+//		"Kind": "<kind>",
+//		"AccountID": "<AccountID>", (optional, "*" or empty for all accounts of the kind)
+//		"ChannelID": "<ChannelID>", (optional)
+// }
+// it responds once the channels are fetched (or after refreshWait) with
+// {
+//		"Refreshed": <number of channels>,
+//		"Done": <whether the fetches are done>
+// }
+func RefreshChannels(s *server.Server, refresh ChannelRefresher) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var refreshData struct {
+			Kind      string
+			AccountID string
+			ChannelID string
+		}
+		err := json.NewDecoder(r.Body).Decode(&refreshData)
+		if err != nil {
+			logging.Println(logging.Error, err)
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if providers.Get(refreshData.Kind) == nil { // before the cooldown, so invalid requests don't consume it
+			rw.WriteHeader(http.StatusBadRequest)
+			logging.Println(logging.Error, "unknown kind", refreshData.Kind)
+			return
+		}
+		sid := s.Sessions.SessionIDFromRequest(r)
+		googleUser := server.GoogleUserInfoFromSession(s, sid)
+		user, err := s.Services.UserService.GetUser(googleUser.Email)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+
+		accounts := []models.Account{}
+		if refreshData.AccountID != "" && refreshData.AccountID != "*" {
+			accountID, _ := strconv.ParseInt(refreshData.AccountID, 10, 64)
+			acc, err := s.Services.AccountService.GetAccount(accountID)
+			if err != nil || acc.UserID != user.ID {
+				rw.WriteHeader(http.StatusBadRequest)
+				logging.Println(logging.Error, "account not found", err)
+				return
+			}
+			accounts = append(accounts, acc)
+		} else {
+			err = s.Services.UserService.LoadUserAccountsForSocialMedia(&user, refreshData.Kind)
+			if err != nil {
+				rw.WriteHeader(http.StatusInternalServerError)
+				logging.Println(logging.Error, err)
+				return
+			}
+			accounts = user.Accounts
+		}
+
+		channelID, _ := strconv.ParseInt(refreshData.ChannelID, 10, 64)
+		channels := []models.Channel{}
+		seen := map[int64]bool{}
+		for _, acc := range accounts {
+			accChannels, err := s.Services.ChannelService.FindChannelsByAccountIDAndKind(acc.ID, acc.Kind)
+			if err != nil {
+				rw.WriteHeader(http.StatusInternalServerError)
+				logging.Println(logging.Error, err)
+				return
+			}
+			for _, c := range accChannels {
+				if !seen[c.ID] && (channelID == 0 || c.ID == channelID) {
+					seen[c.ID] = true
+					channels = append(channels, c)
+				}
+			}
+		}
+		if refreshData.ChannelID != "" && len(channels) == 0 {
+			rw.WriteHeader(http.StatusBadRequest)
+			logging.Println(logging.Error, "channel not found", refreshData.ChannelID)
+			return
+		}
+
+		if remaining := startUserRefresh(user.ID); remaining > 0 {
+			rw.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		refreshed, done := refresh(channels)
+		resp := struct {
+			Refreshed int
+			Done      bool
+		}{Refreshed: refreshed}
+		select {
+		case <-done:
+			resp.Done = true
+		case <-time.After(refreshWait):
+		}
+		json.NewEncoder(rw).Encode(resp)
+	}
+}
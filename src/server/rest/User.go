@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"visual-feed-aggregator/src/server"
 	"visual-feed-aggregator/src/util/logging"
 )
@@ -90,3 +91,79 @@ func DeleteAccount(s *server.Server) http.HandlerFunc {
 		json.NewEncoder(rw).Encode(resp)
 	}
 }
+
+// ContentLabelsFunc returns the content labels of a social media kind
+type ContentLabelsFunc func(kind string) []string
+
+// UpdateAccountFilter sets the content labels, which are hidden in the card view of an account
+// {
+//		"accountID": "<accountID>",
+//		"hiddenLabels": ["short", "live"]
+// }
+func UpdateAccountFilter(s *server.Server, labelsFunc ContentLabelsFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var filterRequest struct {
+			AccountID    string
+			HiddenLabels []string
+		}
+		json.NewDecoder(r.Body).Decode(&filterRequest)
+		sid := s.Sessions.SessionIDFromRequest(r)
+		googleUser := server.GoogleUserInfoFromSession(s, sid)
+		user, err := s.Services.UserService.GetUser(googleUser.Email)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+		accountID, _ := strconv.ParseInt(filterRequest.AccountID, 10, 64)
+		acc, err := s.Services.AccountService.GetAccount(accountID)
+		if err != nil || acc.UserID != user.ID {
+			rw.WriteHeader(http.StatusBadRequest)
+			logging.Println(logging.Error, "account not found", err)
+			return
+		}
+		hiddenLabels := []string{}
+		for _, label := range filterRequest.HiddenLabels {
+			for _, known := range labelsFunc(acc.Kind) {
+				if label == known {
+					hiddenLabels = append(hiddenLabels, label)
+				}
+			}
+		}
+		err = s.Services.AccountService.SetHiddenLabels(accountID, strings.Join(hiddenLabels, ","))
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// UpdateNsfwMode sets whether nsfw & spoiler content is hidden, blurred or shown in the card view
+// {
+//		"nsfwMode": "hide" | "blur" | "show"
+// }
+func UpdateNsfwMode(s *server.Server) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var nsfwRequest struct {
+			NsfwMode string
+		}
+		json.NewDecoder(r.Body).Decode(&nsfwRequest)
+		sid := s.Sessions.SessionIDFromRequest(r)
+		googleUser := server.GoogleUserInfoFromSession(s, sid)
+		user, err := s.Services.UserService.GetUser(googleUser.Email)
+		if err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			logging.Println(logging.Error, err)
+			return
+		}
+		err = s.Services.UserService.SetNsfwMode(user, nsfwRequest.NsfwMode)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			logging.Println(logging.Error, err)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+}
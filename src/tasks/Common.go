@@ -1,71 +1,378 @@
 package tasks
 
 import (
-	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"visual-feed-aggregator/src/database/models"
 	"visual-feed-aggregator/src/database/services"
+	"visual-feed-aggregator/src/providers"
 	"visual-feed-aggregator/src/util/logging"
-
-	"github.com/jmoiron/sqlx"
 )
 
-// BackgroundTask ...
-type BackgroundTask func(stopSignal <-chan bool, lastRun map[string]time.Time,
-	db *sqlx.DB, services *services.ServiceCollection, cutoffDays, refreshRateMinutes int64)
-type taskFunc func(dateCutoff *time.Time, loc *time.Location, services *services.ServiceCollection)
-type channelTaskFunc func(channel *models.Channel, wg *sync.WaitGroup, dateCutoff *time.Time, loc *time.Location,
-	services *services.ServiceCollection)
-
-func runChannelTask(stopSignal <-chan bool, lastRun map[string]time.Time, db *sqlx.DB, srv *services.ServiceCollection, cutoffDays, refreshRateMinutes int64, kind string, channelTask channelTaskFunc) {
-	runTask(stopSignal, lastRun, db, srv, cutoffDays, refreshRateMinutes, kind,
-		func(dateCutoff *time.Time, loc *time.Location, services *services.ServiceCollection) {
-			var wg sync.WaitGroup
-			channels, err := services.ChannelService.FindChannelsByKind(kind)
-			if err == nil {
-				for i := range channels {
-					wg.Add(1)
-					go channelTask(&channels[i], &wg, dateCutoff, loc, services)
-				}
-				wg.Wait()
-			} else {
-				logging.Println(logging.Info, err)
-			}
-		})
-}
-
-func runTask(stopSignal <-chan bool, lastRun map[string]time.Time, db *sqlx.DB, services *services.ServiceCollection, cutoffDays, refreshRateMinutes int64, kind string, task taskFunc) {
-	tick := time.Time{}
-	for true {
-		select {
-		case <-stopSignal:
-			logging.Println(logging.Info, "terminating", kind, "background task")
-			return
-		default:
-			delta := time.Since(tick)
-			if int64(delta.Minutes()) >= refreshRateMinutes {
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				if err := db.PingContext(ctx); err != nil {
-					time.Sleep(1 * time.Minute) // retry in a minute
-				} else {
-					startTime := time.Now()
-					logging.Println(logging.Info, kind, "updating:", startTime.Format(time.RFC3339))
-
-					dateCutoff := time.Now().AddDate(0, 0, -int(cutoffDays))
-					loc := startTime.Location()
-					task(&dateCutoff, loc, services)
-
-					endTime := time.Now()
-					lastRun[kind] = endTime.UTC()
-					logging.Println(logging.Info, kind, "finished updating:", endTime.Format(time.RFC3339), " - it took", endTime.Sub(startTime).Minutes(), "minutes")
-
-					tick = time.Now()
+// pollBounds bound the polling intervals of the channels, which adapt to their posting frequency
+var pollBounds = struct {
+	min, max time.Duration
+}{5 * time.Minute, 6 * time.Hour}
+
+// SetPollingBounds sets the minimum & maximum polling interval of the channels
+func SetPollingBounds(min, max time.Duration) {
+	if min <= 0 || max < min {
+		logging.Println(logging.Warn, "invalid polling bounds", min, max, "- keeping the defaults")
+		return
+	}
+	pollBounds.min, pollBounds.max = min, max
+}
+
+// pollInterval derives the polling interval of a channel from its posting frequency:
+// half the average gap between its posts within the window, bounded by the polling bounds
+func pollInterval(posts int64, window time.Duration) time.Duration {
+	if posts <= 0 {
+		return pollBounds.max
+	}
+	interval := window / time.Duration(2*posts)
+	if interval < pollBounds.min {
+		return pollBounds.min
+	}
+	if interval > pollBounds.max {
+		return pollBounds.max
+	}
+	return interval
+}
+
+// maxFailureBackoff bounds the backoff of failing channels
+const maxFailureBackoff = 7 * 24 * time.Hour
+
+// failureBackoff doubles the polling interval of a failing channel with every consecutive failure
+func failureBackoff(interval time.Duration, failures int) time.Duration {
+	for i := 1; i < failures && interval < maxFailureBackoff; i++ {
+		interval *= 2
+	}
+	if interval > maxFailureBackoff {
+		return maxFailureBackoff
+	}
+	return interval
+}
+
+// fetchJitter is the maximum random delay of a fetch, so the fetches of a run don't all hit the upstream hosts at once
+const fetchJitter = 2 * time.Second
+
+type fetchRequest struct {
+	p     providers.Provider
+	job   *providers.Job
+	stats *fetchStats
+	wg    *sync.WaitGroup
+}
+
+// fetchStats collect the outcome of the fetches of a task run
+type fetchStats struct {
+	notModified int32
+	failed      int32
+	inserted    int64
+	updated     int64
+
+	mu        sync.Mutex
+	lastError string
+}
+
+func (st *fetchStats) fail(err error) {
+	atomic.AddInt32(&st.failed, 1)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastError = err.Error()
+}
+
+// countingContentService counts the inserted & updated content of a task run
+type countingContentService struct {
+	services.ContentService
+	stats *fetchStats
+}
+
+func (s countingContentService) CreateContent(content *models.Content) error {
+	err := s.ContentService.CreateContent(content)
+	if err == nil {
+		atomic.AddInt64(&s.stats.inserted, 1)
+	}
+	return err
+}
+
+func (s countingContentService) UpdateContent(content models.Content) error {
+	err := s.ContentService.UpdateContent(content)
+	if err == nil {
+		atomic.AddInt64(&s.stats.updated, 1)
+	}
+	return err
+}
+
+func (s countingContentService) UpdateContentStats(content models.Content) error {
+	err := s.ContentService.UpdateContentStats(content)
+	if err == nil {
+		atomic.AddInt64(&s.stats.updated, 1)
+	}
+	return err
+}
+
+func (s countingContentService) RemoveLiveContent(channelID int64) (int64, error) {
+	removed, err := s.ContentService.RemoveLiveContent(channelID)
+	atomic.AddInt64(&s.stats.updated, removed)
+	return removed, err
+}
+
+// fetchPool is the pool of fetch workers, which is shared by the channel tasks of all kinds
+var fetchPool = struct {
+	once    sync.Once
+	workers int
+	queue   chan fetchRequest
+}{workers: 8, queue: make(chan fetchRequest)}
+
+// SetFetchWorkers sets the size of the worker pool, which fetches the channels of all kinds. it has to be set before the tasks start
+func SetFetchWorkers(workers int) {
+	if workers < 1 {
+		logging.Println(logging.Warn, "invalid amount of fetch workers", workers, "- keeping the default")
+		return
+	}
+	fetchPool.workers = workers
+}
+
+// fetchClaimTTL is the lifetime of the claim of a fetch in the database, in case an instance dies while fetching
+const fetchClaimTTL = 15 * time.Minute
+
+// fetching holds the channels, which are being fetched by this instance, so scheduled & on-demand fetches don't fetch a channel twice at once
+var fetching = struct {
+	sync.Mutex
+	ids map[int64]bool
+}{ids: map[int64]bool{}}
+
+// claimFetch marks a channel as being fetched, it returns false if it's being fetched already
+func claimFetch(channelID int64) bool {
+	fetching.Lock()
+	defer fetching.Unlock()
+	if fetching.ids[channelID] {
+		return false
+	}
+	fetching.ids[channelID] = true
+	return true
+}
+
+// releaseFetch releases the claims of a channel in this instance & in the database
+func releaseFetch(channelID int64, services *services.ServiceCollection) {
+	if err := services.ChannelService.ReleaseChannelFetch(channelID); err != nil {
+		logging.Println(logging.Error, err)
+	}
+	fetching.Lock()
+	defer fetching.Unlock()
+	delete(fetching.ids, channelID)
+}
+
+// claimChannels claims the channels of the registered providers, which aren't being fetched already by any instance
+func claimChannels(channels []models.Channel, services *services.ServiceCollection) []models.Channel {
+	claimed := []models.Channel{}
+	for _, c := range channels {
+		if providers.Get(c.Kind) == nil || !claimFetch(c.ID) {
+			continue
+		}
+		ok, err := services.ChannelService.ClaimChannelFetch(c.ID, fetchClaimTTL)
+		if err != nil {
+			logging.Println(logging.Error, err)
+		}
+		if !ok {
+			fetching.Lock()
+			delete(fetching.ids, c.ID)
+			fetching.Unlock()
+			continue
+		}
+		claimed = append(claimed, c)
+	}
+	return claimed
+}
+
+// scheduleBudget leaves out the channels, which exceed the budget of a budgeted provider, & marks them as skipped
+func scheduleBudget(p providers.Provider, services *services.ServiceCollection, channels []models.Channel) []models.Channel {
+	b, ok := p.(providers.Budgeted)
+	if !ok {
+		return channels
+	}
+	all, err := services.ChannelService.FindChannelsByKind(p.Kind())
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return nil
+	}
+	channels, skipped := b.Schedule(channels, all)
+	for i := range skipped {
+		if skipped[i].FetchStatus == providers.StatusSkippedBudget {
+			continue
+		}
+		skipped[i].FetchStatus = providers.StatusSkippedBudget
+		if err := services.ChannelService.UpdateFetchStatus(skipped[i]); err != nil {
+			logging.Println(logging.Error, err)
+		}
+	}
+	return channels
+}
+
+// enqueueFetch blocks until a worker picks up the fetch
+func enqueueFetch(req fetchRequest) {
+	fetchPool.once.Do(func() {
+		for i := 0; i < fetchPool.workers; i++ {
+			go fetchWorker()
+		}
+	})
+	fetchPool.queue <- req
+}
+
+func fetchWorker() {
+	for req := range fetchPool.queue {
+		time.Sleep(time.Duration(rand.Int63n(int64(fetchJitter))))
+		fetchChannel(req.p, req.job, req.stats, req.wg)
+	}
+}
+
+// fetchDueChannels fetches the channels, whose next fetch is due. runs without due channels are left out of the history
+func fetchDueChannels(p providers.Provider, services *services.ServiceCollection, cutoffDays int64, run *models.TaskRun) bool {
+	kind := p.Kind()
+	channels, err := services.ChannelService.FindDueChannelsByKind(kind, run.StartedAt)
+	if err != nil {
+		logging.Println(logging.Info, err)
+		return false
+	}
+	channels = claimChannels(scheduleBudget(p, services, channels), services)
+	if len(channels) == 0 {
+		return false
+	}
+	fetchChannels(channels, services, cutoffDays, run)
+	logging.Println(logging.Info, kind, "fetched", run.Channels, "due channels in", time.Since(run.StartedAt).Round(time.Second))
+	return true
+}
+
+// fetchChannels fetches claimed channels via the worker pool & sums up their outcome in the run.
+// the fetches of paced providers are dispatched at the pace of their rate limit
+func fetchChannels(channels []models.Channel, srv *services.ServiceCollection, cutoffDays int64, run *models.TaskRun) {
+	var wg sync.WaitGroup
+	stats := &fetchStats{}
+	counting := *srv
+	counting.ContentService = countingContentService{ContentService: srv.ContentService, stats: stats}
+	startTime := time.Now()
+	dateCutoff := startTime.AddDate(0, 0, -int(cutoffDays))
+	dispatched := 0
+	for i := range channels {
+		p := providers.Get(channels[i].Kind)
+		if paced, ok := p.(providers.Paced); ok {
+			if err := paced.Pace(); err != nil { // the rest is fetched once the rate limit allows
+				for j := i; j < len(channels); j++ {
+					deferFetch(&channels[j], err, srv)
+					releaseFetch(channels[j].ID, srv)
 				}
-				cancel()
-			} else {
-				time.Sleep(5 * time.Second)
+				break
 			}
 		}
+		dispatched++
+		wg.Add(1)
+		enqueueFetch(fetchRequest{p: p, job: &providers.Job{
+			Channel:    &channels[i],
+			DateCutoff: dateCutoff,
+			Loc:        startTime.Location(),
+			Services:   &counting,
+		}, stats: stats, wg: &wg})
+	}
+	wg.Wait()
+
+	run.Channels = dispatched
+	run.Items = int(stats.inserted)
+	run.Updated = int(stats.updated)
+	run.Errors = int(stats.failed)
+	summary := []string{}
+	if stats.notModified > 0 {
+		logging.Println(logging.Info, run.Task, "saved", stats.notModified, "of", dispatched, "fetches, the channels weren't modified")
+		summary = append(summary, fmt.Sprintf("%d of %d channels weren't modified", stats.notModified, dispatched))
+	}
+	if deferred := len(channels) - dispatched; deferred > 0 {
+		summary = append(summary, fmt.Sprintf("%d postponed by the rate limit", deferred))
+	}
+	if stats.failed > 0 {
+		summary = append(summary, fmt.Sprintf("%d failed, the last with: %s", stats.failed, stats.lastError))
+	}
+	run.Message = strings.Join(summary, "; ")
+}
+
+// fetchChannel fetches a claimed channel & records the outcome, the channel is released afterwards
+func fetchChannel(p providers.Provider, job *providers.Job, stats *fetchStats, wg *sync.WaitGroup) {
+	defer wg.Done()
+	channel := job.Channel
+	defer releaseFetch(channel.ID, job.Services)
+	err := p.Fetch(job)
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		job.Complete()
+		recordSuccess(channel, now)
+	case err == providers.ErrNotModified:
+		atomic.AddInt32(&stats.notModified, 1)
+		recordSuccess(channel, now)
+	case errors.Is(err, providers.ErrBudgetExhausted): // the channel stays due & is picked by the budget later on
+		channel.FetchStatus = providers.StatusSkippedBudget
+		if err := job.Services.ChannelService.UpdateFetchStatus(*channel); err != nil {
+			logging.Println(logging.Error, err)
+		}
+		return
+	case errors.Is(err, providers.ErrRateLimited):
+		deferFetch(channel, err, job.Services)
+		return
+	default:
+		logging.Println(logging.Error, "Channel:", channel.Name, "--Error:", err)
+		stats.fail(err)
+		recordFailure(channel, err, now)
+	}
+	channel.LastFetch = sql.NullTime{Time: now, Valid: true}
+	posts, err := job.Services.ContentService.CountChannelContentSince(channel.ID, job.DateCutoff)
+	if err != nil {
+		logging.Println(logging.Error, err)
+	}
+	interval := pollInterval(posts, now.Sub(job.DateCutoff))
+	if c, ok := p.(providers.PollCapped); ok && interval > c.MaxPollInterval() {
+		interval = c.MaxPollInterval()
+	}
+	interval = failureBackoff(interval, channel.FailureCount)
+	channel.NextFetch = sql.NullTime{Time: now.Add(interval), Valid: true}
+	if err := job.Services.ChannelService.UpdateFetchStatus(*channel); err != nil {
+		logging.Println(logging.Error, err)
+	}
+}
+
+// deferFetch postpones the fetch of a rate limited channel until the upstream allows requests again, it isn't a failure of the channel
+func deferFetch(channel *models.Channel, err error, services *services.ServiceCollection) {
+	retry := providers.RetryAt(err)
+	if retry.IsZero() {
+		retry = time.Now().Add(pollBounds.min)
+	}
+	channel.FetchStatus = err.Error()
+	channel.NextFetch = sql.NullTime{Time: retry.UTC(), Valid: true}
+	if err := services.ChannelService.UpdateFetchStatus(*channel); err != nil {
+		logging.Println(logging.Error, err)
+	}
+}
+
+func recordSuccess(channel *models.Channel, now time.Time) {
+	channel.FetchStatus = ""
+	channel.LastSuccess = sql.NullTime{Time: now, Valid: true}
+	channel.FailureCount = 0
+	channel.FailingSince = sql.NullTime{}
+}
+
+// recordFailure keeps the error of a failed fetch, the consecutive failures back off the polling of the channel
+func recordFailure(channel *models.Channel, err error, now time.Time) {
+	channel.FetchStatus = err.Error()
+	if len(channel.FetchStatus) > 255 {
+		channel.FetchStatus = channel.FetchStatus[:255]
+	}
+	channel.LastError = channel.FetchStatus
+	channel.HTTPStatus = providers.HTTPStatus(err)
+	if channel.FailureCount == 0 {
+		channel.FailingSince = sql.NullTime{Time: now, Valid: true}
 	}
+	channel.FailureCount++
 }
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollInterval(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		posts    int64
+		window   time.Duration
+		expected time.Duration
+	}{
+		{0, week, 6 * time.Hour},       // silent channels are polled at the maximum interval
+		{-1, week, 6 * time.Hour},      // invalid counts as well
+		{1, week, 6 * time.Hour},       // half the gap is 3.5 days, above the maximum
+		{14, week, 6 * time.Hour},      // exactly the maximum
+		{28, week, 3 * time.Hour},      // four posts a day
+		{168, week, 30 * time.Minute},  // one post an hour
+		{10000, week, 5 * time.Minute}, // below the minimum
+		{24, 24 * time.Hour, 30 * time.Minute},
+	}
+	for _, test := range tests {
+		if interval := pollInterval(test.posts, test.window); interval != test.expected {
+			t.Errorf("%d posts in %v: interval is %v, expected %v", test.posts, test.window, interval, test.expected)
+		}
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{time.Hour, 0, time.Hour},
+		{time.Hour, 1, time.Hour},
+		{time.Hour, 2, 2 * time.Hour},
+		{time.Hour, 4, 8 * time.Hour},
+		{time.Hour, 8, 128 * time.Hour},
+		{time.Hour, 9, maxFailureBackoff}, // 256h are capped
+		{time.Hour, 1000, maxFailureBackoff},
+		{10 * 24 * time.Hour, 1, maxFailureBackoff},
+	}
+	for _, test := range tests {
+		if backoff := failureBackoff(test.interval, test.failures); backoff != test.expected {
+			t.Errorf("%v after %d failures: backoff is %v, expected %v", test.interval, test.failures, backoff, test.expected)
+		}
+	}
+}
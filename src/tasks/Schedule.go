@@ -0,0 +1,163 @@
+package tasks
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a background task runs next
+type Schedule interface {
+	// Next returns the first run after the given time
+	Next(after time.Time) time.Time
+	String() string
+}
+
+// ParseSchedule parses an interval (i.e. "15m" or "@every 15m"), a shortcut ("@hourly", "@daily", "@weekly")
+// or a cron expression with the fields minute, hour, day of month, month & day of week (i.e. "0 3 * * *")
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every"))); err == nil {
+		if d < time.Second {
+			return nil, errors.New("interval of " + spec + " is too short")
+		}
+		return Every(d), nil
+	}
+	return parseCron(spec)
+}
+
+// Every creates a schedule, which runs a task in a fixed interval
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(s))
+}
+
+func (s everySchedule) String() string {
+	return "@every " + time.Duration(s).String()
+}
+
+// cronSchedule holds the allowed values of each field as bit sets
+type cronSchedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7}, // 0 & 7 are sunday
+}
+
+func parseCron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, errors.New("invalid schedule " + spec + ", expected an interval or a cron expression with 5 fields")
+	}
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, errors.New("invalid " + cronFields[i].name + " of schedule " + spec + ": " + err.Error())
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &cronSchedule{
+		spec:   spec,
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		// like cron, a field starting with "*" is unrestricted, i.e. "*/2" (but not "1-31")
+		anyDom: strings.HasPrefix(fields[2], "*"), anyDow: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma separated list of "*", "n", "a-b" optionally followed by a step ("*/5", "0-30/10")
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if split := strings.SplitN(part, "/", 2); len(split) == 2 {
+			n, err := strconv.Atoi(split[1])
+			if err != nil || n < 1 {
+				return 0, errors.New("invalid step " + split[1])
+			}
+			part, step = split[0], n
+		}
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, err
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, errors.New(part + " is out of range")
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case s.month&(1<<uint(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{} // never, i.e. the 31st of february
+}
+
+// dayMatches follows cron: if both the day of month & the day of week are restricted, either has to match
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (s *cronSchedule) String() string {
+	return s.spec
+}
@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	after := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC) // sunday
+	tests := []struct {
+		spec string
+		next time.Time // zero, if the schedule never runs
+	}{
+		{"15m", after.Add(15 * time.Minute)},
+		{"@every 1h", after.Add(time.Hour)},
+		{"@hourly", time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC)},
+		{"7 10 * * *", time.Date(2026, 10, 19, 10, 7, 0, 0, time.UTC)},
+		{"0,30 9-17/4 * * *", time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * 6", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},   // either day restriction matches
+		{"0 0 */1 * 6", time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC)},  // "*/1" is unrestricted, only saturdays match
+		{"0 0 1-31 * 6", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}, // "1-31" is restricted, every day matches
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(after); !next.Equal(test.next) {
+			t.Errorf("%q: next run is %v, expected %v", test.spec, next, test.next)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"100ms",
+		"0 3 * *",
+		"0 3 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
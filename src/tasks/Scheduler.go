@@ -0,0 +1,366 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"visual-feed-aggregator/src/database/models"
+	"visual-feed-aggregator/src/database/services"
+	"visual-feed-aggregator/src/providers"
+	"visual-feed-aggregator/src/util/logging"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// leaseTTL is the lifetime of a task lease, the leases are renewed by a heartbeat.
+// the tasks of an instance, which died, are taken over once their leases expired
+const leaseTTL = 2 * time.Minute
+
+// heartbeat is the interval, in which the leases are renewed & the last runs of other instances are picked up
+const heartbeat = leaseTTL / 4
+
+// Task is a background task, which runs on its schedule
+type Task struct {
+	Name     string
+	Schedule Schedule
+	// run does the work & fills in its outcome, it returns false if there was nothing to do.
+	// such runs aren't kept in the history, i.e. channel tasks without due channels
+	run func(s *Scheduler, run *models.TaskRun) bool
+	// everyInstance tasks maintain the state of each instance (i.e. the mirror health), they don't need a lease
+	everyInstance bool
+}
+
+// TaskStatus is the current state of a task, for the status page
+type TaskStatus struct {
+	Name     string
+	Schedule string
+	LastRun  time.Time
+	NextRun  time.Time // zero if it never runs again
+	Running  bool
+	Owner    string // instance, which holds the lease of the task
+	Local    bool   // whether this instance runs the task
+}
+
+// Scheduler runs the background tasks on their schedules & keeps the history of their runs in the database.
+// the schedules continue from the last runs of the history after a restart.
+// if several instances share the database, each task is run by a single instance, which holds its lease
+type Scheduler struct {
+	db         *sqlx.DB
+	services   *services.ServiceCollection
+	cutoffDays int64
+	instance   string
+	started    time.Time
+	stop       chan struct{}
+	runs       sync.WaitGroup // running tasks & refreshes
+
+	mu       sync.Mutex
+	stopped  bool
+	tasks    []Task
+	lastRun  map[string]time.Time // end of the last run by task
+	snapshot map[string]time.Time // end of the last run, which changed content, by task
+	nextRun  map[string]time.Time
+	running  map[string]bool
+	owners   map[string]string // lease owner by task
+}
+
+// NewScheduler creates a scheduler, which picks up the last runs of the tasks from the database
+func NewScheduler(db *sqlx.DB, services *services.ServiceCollection, cutoffDays int64) *Scheduler {
+	s := &Scheduler{
+		db:         db,
+		services:   services,
+		cutoffDays: cutoffDays,
+		instance:   instanceID(),
+		started:    time.Now().UTC(),
+		stop:       make(chan struct{}),
+		lastRun:    map[string]time.Time{},
+		snapshot:   map[string]time.Time{},
+		nextRun:    map[string]time.Time{},
+		running:    map[string]bool{},
+		owners:     map[string]string{},
+	}
+	s.sync()
+	return s
+}
+
+// instanceID identifies this instance in the task leases, the host name is the container id in docker
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "vifa"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// Start runs every task on its schedule until the scheduler is stopped
+func (s *Scheduler) Start(tasks []Task) {
+	s.mu.Lock()
+	s.tasks = append(s.tasks, tasks...)
+	s.mu.Unlock()
+	logging.Println(logging.Info, "starting the background tasks as instance", s.instance)
+	for _, t := range tasks {
+		go s.loop(t)
+	}
+	go s.heartbeat()
+}
+
+// Stop stops scheduling the tasks & waits up to the timeout for the running tasks. once they finished, their leases are released,
+// so other instances take them over right away. otherwise the leases are kept until they expire, so no other instance runs a task twice
+func (s *Scheduler) Stop(timeout time.Duration) {
+	s.mu.Lock()
+	s.stopped = true
+	close(s.stop)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logging.Println(logging.Warn, "the running background tasks didn't finish in", timeout, "- their leases expire in", leaseTTL)
+		return
+	}
+	if err := s.services.TaskService.ReleaseTaskLeases(s.instance); err != nil {
+		logging.Println(logging.Error, err)
+	}
+}
+
+// track adds a run to the running tasks, unless the scheduler was stopped
+func (s *Scheduler) track() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.runs.Add(1)
+	return true
+}
+
+// heartbeat renews the leases of this instance & syncs the state of the tasks with the database
+func (s *Scheduler) heartbeat() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(heartbeat):
+			if err := s.services.TaskService.RenewTaskLeases(s.instance, leaseTTL); err != nil {
+				logging.Println(logging.Error, "could not renew the task leases:", err)
+			}
+			s.sync()
+		}
+	}
+}
+
+// sync picks up the last runs & the lease owners from the database, the runs of other instances are the snapshots of their kinds as well
+func (s *Scheduler) sync() {
+	runs, err := s.services.TaskService.FindLastTaskRuns(false)
+	if err != nil {
+		logging.Println(logging.Error, "could not load the last task runs:", err)
+	}
+	changes, err := s.services.TaskService.FindLastTaskRuns(true)
+	if err != nil {
+		logging.Println(logging.Error, "could not load the last task runs:", err)
+	}
+	leases, err := s.services.TaskService.FindTaskLeases()
+	if err != nil {
+		logging.Println(logging.Error, "could not load the task leases:", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range runs {
+		if run.FinishedAt.After(s.lastRun[run.Task]) {
+			s.lastRun[run.Task] = run.FinishedAt
+		}
+	}
+	for _, run := range changes {
+		if run.FinishedAt.After(s.snapshot[run.Task]) {
+			s.snapshot[run.Task] = run.FinishedAt
+		}
+	}
+	s.owners = map[string]string{}
+	for _, lease := range leases {
+		s.owners[lease.Task] = lease.Owner
+	}
+}
+
+// acquire takes the lease of a task, if no other instance holds it
+func (s *Scheduler) acquire(t Task) bool {
+	if t.everyInstance {
+		return true
+	}
+	acquired, err := s.services.TaskService.AcquireTaskLease(t.Name, s.instance, leaseTTL)
+	if err != nil {
+		logging.Println(logging.Error, "could not acquire the lease of", t.Name, "-", err)
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if acquired && s.owners[t.Name] != s.instance {
+		logging.Println(logging.Info, "instance", s.instance, "took over the", t.Name, "background task")
+		s.owners[t.Name] = s.instance
+	}
+	return acquired
+}
+
+// Snapshot returns the snapshot time of a kind: the end of the last run of its task, which changed content.
+// runs, which only found unchanged channels, don't invalidate the cached views. it's the start of the scheduler, if no run changed content
+func (s *Scheduler) Snapshot(kind string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snapshot, ok := s.snapshot[kind]; ok {
+		return snapshot
+	}
+	return s.started
+}
+
+// Status returns the state of all tasks, sorted by name
+func (s *Scheduler) Status() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]TaskStatus, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		status := TaskStatus{
+			Name:     t.Name,
+			Schedule: t.Schedule.String(),
+			LastRun:  s.lastRun[t.Name],
+			NextRun:  s.nextRun[t.Name],
+			Running:  s.running[t.Name],
+			Owner:    s.owners[t.Name],
+			Local:    t.everyInstance || s.owners[t.Name] == s.instance,
+		}
+		if t.everyInstance {
+			status.Owner = "every instance"
+		}
+		ret = append(ret, status)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func (s *Scheduler) loop(t Task) {
+	s.mu.Lock()
+	last, ok := s.lastRun[t.Name]
+	s.mu.Unlock()
+	next := t.Schedule.Next(time.Now())
+	if ok {
+		next = t.Schedule.Next(last) // overdue runs are caught up right away
+	}
+	for {
+		s.mu.Lock()
+		s.nextRun[t.Name] = next
+		s.mu.Unlock()
+
+		var due <-chan time.Time // never, if the schedule has no next run
+		if !next.IsZero() {
+			due = time.After(time.Until(next))
+		}
+		select {
+		case <-s.stop:
+			logging.Println(logging.Info, "terminating", t.Name, "background task")
+			return
+		case <-due:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.db.PingContext(ctx)
+		cancel()
+		if err != nil {
+			logging.Println(logging.Warn, t.Name, "background task postponed, the database is unreachable:", err)
+			next = time.Now().Add(1 * time.Minute) // retry in a minute
+			continue
+		}
+		if s.acquire(t) { // otherwise another instance runs the task
+			s.runTask(t)
+		}
+		next = t.Schedule.Next(time.Now())
+	}
+}
+
+func (s *Scheduler) runTask(t Task) {
+	if !s.track() {
+		return
+	}
+	defer s.runs.Done()
+	s.setRunning(t.Name, true)
+	defer s.setRunning(t.Name, false)
+	run := models.TaskRun{Task: t.Name, TriggeredBy: models.TriggerSchedule, StartedAt: time.Now().UTC()}
+	if t.run(s, &run) {
+		s.record(&run)
+	}
+}
+
+func (s *Scheduler) setRunning(name string, running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[name] = running
+}
+
+// record finishes a run & adds it to the history
+func (s *Scheduler) record(run *models.TaskRun) {
+	run.FinishedAt = time.Now().UTC()
+	if len(run.Message) > 255 {
+		run.Message = run.Message[:255]
+	}
+	if err := s.services.TaskService.CreateTaskRun(run); err != nil {
+		logging.Println(logging.Error, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun[run.Task] = run.FinishedAt
+	if run.Items > 0 || run.Updated > 0 {
+		s.snapshot[run.Task] = run.FinishedAt
+	}
+}
+
+// Refresh fetches channels right away, i.e. requested by a user or after their creation. channels being fetched already by any instance
+// & channels exceeding the budget of their provider are left out.
+// the fetches are recorded as runs of the tasks of their kinds, so they count as snapshots.
+// it returns how many channels are fetched & a channel, which is closed once they are done. nothing is fetched once the scheduler was stopped
+func (s *Scheduler) Refresh(channels []models.Channel) (int, <-chan struct{}) {
+	done := make(chan struct{})
+	if !s.track() {
+		close(done)
+		return 0, done
+	}
+	byKind := map[string][]models.Channel{}
+	for _, c := range channels {
+		byKind[c.Kind] = append(byKind[c.Kind], c)
+	}
+	claimed := 0
+	for kind, kindChannels := range byKind {
+		if p := providers.Get(kind); p != nil {
+			kindChannels = scheduleBudget(p, s.services, kindChannels)
+		}
+		byKind[kind] = claimChannels(kindChannels, s.services)
+		claimed += len(byKind[kind])
+		if len(byKind[kind]) == 0 {
+			delete(byKind, kind)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for kind, kindChannels := range byKind {
+		wg.Add(1)
+		go func(kind string, channels []models.Channel) {
+			defer wg.Done()
+			run := models.TaskRun{Task: kind, TriggeredBy: models.TriggerRefresh, StartedAt: time.Now().UTC()}
+			fetchChannels(channels, s.services, s.cutoffDays, &run)
+			s.record(&run)
+			logging.Println(logging.Info, kind, "refreshed", run.Channels, "channels on demand in", time.Since(run.StartedAt).Round(time.Second))
+		}(kind, kindChannels)
+	}
+	go func() {
+		defer s.runs.Done()
+		wg.Wait()
+		close(done)
+	}()
+	return claimed, done
+}
@@ -1,5 +1,6 @@
 let header = document.querySelector("#header");
-let headers = header.querySelectorAll("li");
+let headers = header.querySelectorAll("li[data-id]");
+let refreshBtn = header.querySelector("#refresh");
 let csrf = document.querySelector("#csrf").content;
 let lastActiveHeader;
 
@@ -55,6 +56,31 @@ for (let hdr of headers) {
     });
 }
 
+refreshBtn.addEventListener("click", e => {
+    if (refreshBtn.classList.contains("busy")) return;
+    refreshBtn.classList.add("busy");
+    refreshBtn.firstElementChild.classList.add("fa-spin");
+    fetch("/api/v1/refresh", {
+        method: "POST",
+        headers: {
+            "csrf": csrf,
+        },
+        body: JSON.stringify({
+            "accountID": currentId,
+            "kind": currentKind,
+        }),
+    })
+    .then(resp => {
+        if (resp.status == 429) {
+            alert(`please wait ${resp.headers.get("Retry-After")} seconds before the next refresh`);
+        }
+        refreshBtn.classList.remove("busy");
+        refreshBtn.firstElementChild.classList.remove("fa-spin");
+        updateCards(currentId, currentKind);
+        queryContentCount();
+    });
+});
+
 function updateStatus() {
     statusTxt.textContent = `${page+1}/${maxPage+1}`;
 }
@@ -1,7 +1,4 @@
-var kind = "youtube";
-
 var opmlFile = document.querySelector("#opml-file");
-var btnOpmlFile = document.querySelector("#btn-opml-file");
 var msgWait = document.querySelector("#wait");
 
 opmlFile.addEventListener("change", e => {
@@ -11,6 +8,7 @@ opmlFile.addEventListener("change", e => {
     var fd = new FormData();
     fd.append("opml-file", opmlFile.files[0]);
     fd.append("accountID", lastAccountSelection);
+    fd.append("kind", kind);
     msgWait.classList.remove("d-none");
     disable(btnOpmlFile);
     disable(accountSelection);
@@ -0,0 +1,25 @@
+var csrf = document.querySelector("#csrf").content;
+var nsfwMode = document.querySelector("#nsfw-mode");
+var lastNsfwMode = nsfwMode.value;
+
+nsfwMode.addEventListener("change", e => {
+    fetch("/api/v1/user/nsfw", {
+        method: "POST",
+        headers: {
+            "csrf": csrf,
+        },
+        body: JSON.stringify({
+            "nsfwMode": nsfwMode.value,
+        }),
+    })
+    .then(resp => {
+        if (resp.ok) {
+            lastNsfwMode = nsfwMode.value;
+        } else {
+            nsfwMode.value = lastNsfwMode;
+        }
+    })
+    .catch(err => {
+        nsfwMode.value = lastNsfwMode;
+    });
+});
@@ -1 +0,0 @@
-var kind = "reddit";
\ No newline at end of file
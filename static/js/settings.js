@@ -1,3 +1,4 @@
+var kind = document.querySelector("main[data-kind]").dataset.kind;
 var accountName = document.querySelector("#account-name");
 var btnAddAccount = document.querySelector("#btn-add-account");
 var btnDelAccount = document.querySelector("#btn-del-account");
@@ -9,10 +10,12 @@ var channelTable = document.querySelector("#channelTable");
 var lastAddedAccount = "";
 var lastAddedChannel = "";
 var csrf = document.querySelector("#csrf").content;
+var btnOpmlFile = document.querySelector("#btn-opml-file");
+var accountFilter = document.querySelectorAll("#account-filter input[type=checkbox]");
 
 disable(btnAddAccount);
 if (!lastAccountSelection) {
-    if (kind === "youtube") {
+    if (btnOpmlFile) {
         disable(btnOpmlFile);
     }
     disable(btnAddChannel);
@@ -20,6 +23,7 @@ if (!lastAccountSelection) {
 } else {
     fillTable();
 }
+syncAccountFilter();
 accountName.addEventListener("keyup", e => {
     if (e.keyCode == 13) {
         btnAddAccount.click();        
@@ -81,7 +85,7 @@ btnDelAccount.addEventListener("click", e => {
         renderAccountSelection(data.LastID);
         if (data.LastID == -1) {
             lastAccountSelection = "";
-            if (kind === "youtube") {
+            if (btnOpmlFile) {
                 disable(btnOpmlFile);
             }
             disable(btnAddChannel);
@@ -107,6 +111,7 @@ function accountSelectionChangeEvent(e) {
         lastAccountSelection = e.target.value;
         lastAddedChannel = "";
         fillTable();
+        syncAccountFilter();
     }
 }
 accountSelection.addEventListener("change", accountSelectionChangeEvent);
@@ -197,6 +202,27 @@ function deleteChannelFromAccount(id) {
         fillTable();
     });
 }
+function refreshChannel(id, btn) {
+    disable(btn);
+    btn.firstElementChild.classList.add("fa-spin");
+    fetch("/api/v1/refresh", {
+        method: "POST",
+        headers: {
+            "csrf": csrf,
+        },
+        body: JSON.stringify({
+            "channelID": id,
+            "accountID": lastAccountSelection,
+            "kind": kind,
+        }),
+    })
+    .then(resp => {
+        if (resp.status == 429) {
+            alert(`please wait ${resp.headers.get("Retry-After")} seconds before the next refresh`);
+        }
+        fillTable();
+    });
+}
 function renderAccountSelection(id) {
     fetch("/partial-renderer/settings-account-selection", {
         method: "POST",
@@ -213,12 +239,48 @@ function renderAccountSelection(id) {
         document.querySelector(".select-wrapper").outerHTML = data;
         accountSelection = document.querySelector("#account-selection");
         accountSelection.addEventListener("change", accountSelectionChangeEvent);
-        if (lastAccountSelection && kind === "youtube") {
+        if (lastAccountSelection && btnOpmlFile) {
             enable(btnOpmlFile);
         }
         fillTable()
+        syncAccountFilter();
     })
 }
+accountFilter.forEach(checkbox => checkbox.addEventListener("change", e => {
+    if (!lastAccountSelection) {
+        return;
+    }
+    let hiddenLabels = Array.from(accountFilter).filter(c => c.checked).map(c => c.value);
+    fetch("/api/v1/account/filter", {
+        method: "POST",
+        headers: {
+            "csrf": csrf,
+        },
+        body: JSON.stringify({
+            "accountID": lastAccountSelection,
+            "hiddenLabels": hiddenLabels,
+        }),
+    })
+    .then(resp => {
+        if (resp.ok) {
+            accountSelection.selectedOptions[0].dataset.hiddenLabels = hiddenLabels.join(",");
+        } else {
+            syncAccountFilter();
+        }
+    });
+}));
+function syncAccountFilter() {
+    let option = accountSelection.selectedOptions[0];
+    let hiddenLabels = option ? option.dataset.hiddenLabels.split(",") : [];
+    accountFilter.forEach(checkbox => {
+        checkbox.checked = hiddenLabels.includes(checkbox.value);
+        if (option) {
+            enable(checkbox);
+        } else {
+            disable(checkbox);
+        }
+    });
+}
 function enable(e) {
     e.removeAttribute("disabled");
 }
@@ -1 +0,0 @@
-var kind = "twitter";